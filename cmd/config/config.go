@@ -2,6 +2,9 @@ package config
 
 import (
 	"log"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
@@ -13,6 +16,7 @@ type Config struct {
 	}
 	App struct {
 		RepoBaseDir string `mapstructure:"repo_base_dir"`
+		DataDir     string `mapstructure:"data_dir"`
 		LogLevel    string `mapstructure:"log_level"`
 	}
 	Git struct {
@@ -21,8 +25,30 @@ type Config struct {
 		DefaultBranch string `mapstructure:"default_branch"`
 		RemoteURL     string `mapstructure:"remote_url"`
 	}
+	Queue struct {
+		Capacity       int           `mapstructure:"capacity"`
+		MaxAttempts    int           `mapstructure:"max_attempts"`
+		InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+		MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+		HistoryLimit   int           `mapstructure:"history_limit"`
+	}
+	Pipeline struct {
+		// Pipeline versions in which unknown keys are only warned about
+		LenientVersions []string `mapstructure:"lenient_versions"`
+		// Module prefixes config.operator and config.callable may import from
+		AllowedImports []string `mapstructure:"allowed_imports"`
+		// Directory of DAG templates overriding the built-in ones
+		TemplateDir string `mapstructure:"template_dir"`
+		// Command run on every generated DAG, e.g. python3 -m py_compile {file}
+		ValidationCommand string        `mapstructure:"validation_command"`
+		ValidationTimeout time.Duration `mapstructure:"validation_timeout"`
+	}
+	Admin struct {
+		Token string `mapstructure:"token"`
+	}
 	Webhook struct {
-		Secret string
+		Secret  string
+		Secrets []string `mapstructure:"secrets"`
 	}
 }
 
@@ -54,8 +80,32 @@ func LoadConfig() (*Config, error) {
 	viper.BindEnv("git.default_branch", "GIT_DEFAULT_BRANCH")
 	viper.BindEnv("git.remote_url", "GIT_REMOTE_URL")
 	viper.BindEnv("webhook.secret", "WEBHOOK_SECRET")
+	viper.BindEnv("webhook.secrets", "WEBHOOK_SECRETS")
 	viper.BindEnv("app.log_level", "LOG_LEVEL")
 	viper.BindEnv("app.repo_base_dir", "REPO_BASE_DIR")
+	viper.BindEnv("app.data_dir", "DATA_DIR")
+	viper.BindEnv("queue.capacity", "QUEUE_CAPACITY")
+	viper.BindEnv("queue.max_attempts", "QUEUE_MAX_ATTEMPTS")
+	viper.BindEnv("queue.initial_backoff", "QUEUE_INITIAL_BACKOFF")
+	viper.BindEnv("queue.max_backoff", "QUEUE_MAX_BACKOFF")
+	viper.BindEnv("queue.history_limit", "JOB_HISTORY_LIMIT")
+	viper.BindEnv("admin.token", "ADMIN_TOKEN")
+	viper.BindEnv("pipeline.lenient_versions", "PIPELINE_LENIENT_VERSIONS")
+	viper.BindEnv("pipeline.allowed_imports", "PIPELINE_ALLOWED_IMPORTS")
+	viper.BindEnv("pipeline.template_dir", "TEMPLATE_DIR")
+	viper.BindEnv("pipeline.validation_command", "DAG_VALIDATION_COMMAND")
+	viper.BindEnv("pipeline.validation_timeout", "DAG_VALIDATION_TIMEOUT")
+
+	// Defaults
+	viper.SetDefault("git.default_branch", "main")
+	viper.SetDefault("app.data_dir", "./data")
+	viper.SetDefault("queue.capacity", 100)
+	viper.SetDefault("queue.max_attempts", 5)
+	viper.SetDefault("queue.initial_backoff", "10s")
+	viper.SetDefault("queue.max_backoff", "10m")
+	viper.SetDefault("queue.history_limit", 1000)
+	viper.SetDefault("pipeline.allowed_imports", "airflow.")
+	viper.SetDefault("pipeline.validation_timeout", "30s")
 
 	// Unmarshal configuration into struct
 	var config Config
@@ -75,6 +125,20 @@ func (cfg *Config) Environment() string {
 	return getString("environment", "development")
 }
 
+// WebhookSecrets returns every secret accepted when verifying webhook signatures.
+// The current secret comes first, followed by previous secrets that are still
+// accepted while a rotation is in progress.
+func (cfg *Config) WebhookSecrets() []string {
+	secrets := make([]string, 0, len(cfg.Webhook.Secrets)+1)
+	for _, secret := range append([]string{cfg.Webhook.Secret}, cfg.Webhook.Secrets...) {
+		secret = strings.TrimSpace(secret)
+		if secret != "" && !slices.Contains(secrets, secret) {
+			secrets = append(secrets, secret)
+		}
+	}
+	return secrets
+}
+
 func (cfg *Config) Get(key string, defaultVal string) string {
 	val := viper.GetString(key)
 	if val == "" {
@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Suhaibshah22/pipeweaver/cmd/config"
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/entity"
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/port/queue"
+	"github.com/Suhaibshah22/pipeweaver/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminController struct {
+	ManageJobsUsecase usecase.ManageJobsUsecase
+	Log               *slog.Logger
+	Config            *config.Config
+}
+
+func NewAdminController(
+	manageJobsUsecase usecase.ManageJobsUsecase,
+	logger *slog.Logger,
+	cfg *config.Config,
+) *AdminController {
+	return &AdminController{
+		ManageJobsUsecase: manageJobsUsecase,
+		Log:               logger,
+		Config:            cfg,
+	}
+}
+
+// Authorize only lets requests through that carry the configured admin token
+// as a bearer token. The admin API is disabled when no token is configured.
+func (ac *AdminController) Authorize(c *gin.Context) {
+	if ac.Config.Admin.Token == "" {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin API disabled, set ADMIN_TOKEN to enable it"})
+		return
+	}
+
+	token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !found || subtle.ConstantTimeCompare([]byte(token), []byte(ac.Config.Admin.Token)) != 1 {
+		ac.Log.Warn("Rejecting admin request with invalid token", "path", c.Request.URL.Path, "remoteAddr", c.ClientIP())
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return
+	}
+
+	c.Next()
+}
+
+// ListJobs returns the most recent jobs, newest first. The status query
+// parameter filters by job status and limit caps the number returned.
+func (ac *AdminController) ListJobs(c *gin.Context) {
+	status := entity.JobStatus(c.Query("status"))
+	switch status {
+	case "", entity.JobQueued, entity.JobRunning, entity.JobSucceeded, entity.JobSkipped, entity.JobFailed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown status " + string(status)})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a non-negative number"})
+		return
+	}
+
+	jobs, err := ac.ManageJobsUsecase.ListJobs(c.Request.Context(), status, limit)
+	if err != nil {
+		ac.Log.Error("Error listing jobs", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to list jobs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"jobs": withoutPayloads(jobs)})
+}
+
+func (ac *AdminController) GetJob(c *gin.Context) {
+	id := c.Param("id")
+
+	job, err := ac.ManageJobsUsecase.FindJob(c.Request.Context(), id)
+	switch {
+	case errors.Is(err, queue.ErrJobNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+	case err != nil:
+		ac.Log.Error("Error fetching job", "job", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch job"})
+	default:
+		c.JSON(http.StatusOK, job)
+	}
+}
+
+func (ac *AdminController) ListDeadLetters(c *gin.Context) {
+	jobs, err := ac.ManageJobsUsecase.ListDeadLetters(c.Request.Context())
+	if err != nil {
+		ac.Log.Error("Error listing dead letters", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to list dead letters"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"jobs": withoutPayloads(jobs)})
+}
+
+// withoutPayloads drops the webhook payloads of listed jobs. They are large,
+// so they are only returned when fetching a single job.
+func withoutPayloads(jobs []*entity.Job) []*entity.Job {
+	for _, job := range jobs {
+		job.Payload = nil
+	}
+	return jobs
+}
+
+func (ac *AdminController) RedriveDeadLetter(c *gin.Context) {
+	id := c.Param("id")
+
+	err := ac.ManageJobsUsecase.Redrive(c.Request.Context(), id)
+	switch {
+	case errors.Is(err, queue.ErrJobNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Failed job not found"})
+	case err != nil:
+		ac.Log.Error("Error redriving job", "job", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to redrive job"})
+	default:
+		c.JSON(http.StatusAccepted, gin.H{"status": "Job requeued", "id": id})
+	}
+}
@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/Suhaibshah22/pipeweaver/cmd/config"
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/schema"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SchemaController struct {
+	Log    *slog.Logger
+	Config *config.Config
+}
+
+func NewSchemaController(logger *slog.Logger, cfg *config.Config) *SchemaController {
+	return &SchemaController{
+		Log:    logger,
+		Config: cfg,
+	}
+}
+
+// GetPipelineSchema returns the JSON Schema of pipeline definitions of the
+// requested version, e.g. /schemas/pipeline/v1.0.json. "latest" resolves to
+// the newest version.
+func (sc *SchemaController) GetPipelineSchema(c *gin.Context) {
+	version := strings.TrimSuffix(c.Param("version"), ".json")
+	if version == "latest" {
+		c.JSON(http.StatusOK, schema.Latest())
+		return
+	}
+
+	s, err := schema.For(version)
+	if errors.Is(err, schema.ErrUnknownVersion) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		sc.Log.Error("Failed to generate pipeline schema", "version", version, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate schema"})
+		return
+	}
+
+	c.JSON(http.StatusOK, s)
+}
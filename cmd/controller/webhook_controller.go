@@ -2,10 +2,12 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"log/slog"
 	"net/http"
 
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/port/queue"
 	"github.com/Suhaibshah22/pipeweaver/internal/usecase"
 
 	"github.com/Suhaibshah22/pipeweaver/external"
@@ -15,10 +17,15 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// webhookEventHandler handles one GitHub event type once the delivery has been authenticated.
+type webhookEventHandler func(c *gin.Context, delivery string, body []byte)
+
 type WebhookController struct {
 	ProcessPipelineUsecase usecase.ProcessPipelineUsecase
 	Log                    *slog.Logger
 	Config                 *config.Config
+
+	handlers map[string]webhookEventHandler
 }
 
 func NewWebhookController(
@@ -26,16 +33,22 @@ func NewWebhookController(
 	logger *slog.Logger,
 	cfg *config.Config,
 ) *WebhookController {
-	return &WebhookController{
+	wc := &WebhookController{
 		ProcessPipelineUsecase: ProcessPipelineUsecase,
 		Log:                    logger,
 		Config:                 cfg,
 	}
+
+	wc.handlers = map[string]webhookEventHandler{
+		external.GitHubEventPing:        wc.handlePing,
+		external.GitHubEventPush:        wc.handlePush,
+		external.GitHubEventPullRequest: wc.handlePullRequest,
+	}
+
+	return wc
 }
 
 func (wc *WebhookController) HandleWebhook(c *gin.Context) {
-	var payload external.GitHubWebhookPayload
-
 	// Read the body
 	body, err := io.ReadAll(c.Request.Body)
 	if err != nil {
@@ -44,21 +57,106 @@ func (wc *WebhookController) HandleWebhook(c *gin.Context) {
 		return
 	}
 
-	// Parse JSON
+	// Verify the payload was signed with one of our webhook secrets
+	err = external.VerifyGitHubSignature(body, c.GetHeader(external.GitHubSignatureHeader), wc.Config.WebhookSecrets())
+	if errors.Is(err, external.ErrNoWebhookSecrets) {
+		wc.Log.Error("Rejecting webhook, no webhook secret configured")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Webhook secret not configured"})
+		return
+	}
+	if err != nil {
+		wc.Log.Warn("Rejecting webhook with invalid signature", "error", err, "remoteAddr", c.ClientIP())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid signature"})
+		return
+	}
+
+	// Route the delivery to the handler for its event type
+	event := c.GetHeader(external.GitHubEventHeader)
+	delivery := c.GetHeader(external.GitHubDeliveryHeader)
+	if event == "" {
+		wc.Log.Error("Missing event header", "delivery", delivery)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing " + external.GitHubEventHeader + " header"})
+		return
+	}
+
+	handler, ok := wc.handlers[event]
+	if !ok {
+		wc.Log.Info("Ignoring unsupported event", "event", event, "delivery", delivery)
+		c.JSON(http.StatusOK, gin.H{"status": "ignored", "event": event})
+		return
+	}
+
+	handler(c, delivery, body)
+}
+
+func (wc *WebhookController) handlePing(c *gin.Context, delivery string, body []byte) {
+	var payload external.GitHubPingPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		wc.Log.Error("Error parsing JSON", "event", external.GitHubEventPing, "delivery", delivery, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+
+	wc.Log.Info("Received ping", "delivery", delivery, "hookID", payload.HookID)
+	c.JSON(http.StatusOK, gin.H{"status": "pong"})
+}
+
+func (wc *WebhookController) handlePush(c *gin.Context, delivery string, body []byte) {
+	var payload external.GitHubPushPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		wc.Log.Error("Error parsing JSON", "event", external.GitHubEventPush, "delivery", delivery, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+
+	wc.enqueue(c, usecase.PipelineEvent{
+		DeliveryID: delivery,
+		Event:      external.GitHubEventPush,
+		Push:       &payload,
+	}, payload.Repository.Name)
+}
+
+func (wc *WebhookController) handlePullRequest(c *gin.Context, delivery string, body []byte) {
+	var payload external.GitHubPullRequestPayload
 	if err := json.Unmarshal(body, &payload); err != nil {
-		wc.Log.Error("Error parsing JSON", "error", err)
+		wc.Log.Error("Error parsing JSON", "event", external.GitHubEventPullRequest, "delivery", delivery, "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
 		return
 	}
 
-	// Enqueue Processing the request using the usecase
-	select {
-	case usecase.ProcessPipelinesQueue <- payload:
-		wc.Log.Info("Webhook request enqueued", "repo", payload.Repository.Name)
-		c.JSON(http.StatusAccepted, gin.H{"status": "Webhook request accepted for processing"})
+	// Only new commits on a pull request change its DAG preview
+	switch payload.Action {
+	case external.PullRequestActionOpened, external.PullRequestActionReopened, external.PullRequestActionSynchronize:
 	default:
+		wc.Log.Info("Ignoring pull request action", "action", payload.Action, "delivery", delivery)
+		c.JSON(http.StatusOK, gin.H{"status": "ignored", "event": external.GitHubEventPullRequest, "action": payload.Action})
+		return
+	}
+
+	wc.enqueue(c, usecase.PipelineEvent{
+		DeliveryID:  delivery,
+		Event:       external.GitHubEventPullRequest,
+		PullRequest: &payload,
+	}, payload.Repository.Name)
+}
+
+// enqueue hands the event to the queue worker, rejecting it if the queue is full.
+func (wc *WebhookController) enqueue(c *gin.Context, event usecase.PipelineEvent, repo string) {
+	id, err := wc.ProcessPipelineUsecase.Enqueue(c.Request.Context(), event)
+	switch {
+	case errors.Is(err, queue.ErrQueueFull):
 		// Queue is full, reject request
-		wc.Log.Error("Queue is full, dropping request")
+		wc.Log.Error("Queue is full, dropping request", "event", event.Event, "delivery", event.DeliveryID)
 		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Queue is full, try again later"})
+	case errors.Is(err, queue.ErrJobExists):
+		// Redeliveries are expected, acknowledge them so GitHub does not report a failure
+		wc.Log.Info("Ignoring duplicate webhook", "event", event.Event, "delivery", event.DeliveryID, "job", id)
+		c.JSON(http.StatusOK, gin.H{"status": "duplicate", "job": id})
+	case err != nil:
+		wc.Log.Error("Error enqueuing request", "event", event.Event, "delivery", event.DeliveryID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to accept request"})
+	default:
+		wc.Log.Info("Webhook request enqueued", "repo", repo, "event", event.Event, "delivery", event.DeliveryID, "job", id)
+		c.JSON(http.StatusAccepted, gin.H{"status": "Webhook request accepted for processing", "job": id})
 	}
 }
@@ -7,9 +7,13 @@ import (
 	"github.com/Suhaibshah22/pipeweaver/cmd/config"
 	"github.com/Suhaibshah22/pipeweaver/cmd/controller"
 	"github.com/Suhaibshah22/pipeweaver/external"
+	"github.com/Suhaibshah22/pipeweaver/internal/adapter/queue"
 	"github.com/Suhaibshah22/pipeweaver/internal/adapter/repository"
+	"github.com/Suhaibshah22/pipeweaver/internal/adapter/validator"
+	queueport "github.com/Suhaibshah22/pipeweaver/internal/domain/port/queue"
 	port "github.com/Suhaibshah22/pipeweaver/internal/domain/port/repository"
 	"github.com/Suhaibshah22/pipeweaver/internal/usecase"
+	"github.com/Suhaibshah22/pipeweaver/internal/usecase/templates"
 
 	"log/slog"
 
@@ -26,12 +30,21 @@ type Container struct {
 	// Repositories
 	GitRepository port.GitRepository
 
+	// Queues
+	JobQueue queueport.JobQueue
+	// QueueWorkerDone is closed once the queue worker has stopped
+	QueueWorkerDone chan struct{}
+
 	// Usecases
 	ProcessRepositoryUseCase  usecase.ProcessPipelineUsecase
 	GenerateAirFlowDAGUsecase usecase.GenerateAirFlowDAGUsecase
+	PreviewPipelineUsecase    usecase.PreviewPipelineUsecase
+	ManageJobsUsecase         usecase.ManageJobsUsecase
 
 	// Controllers
 	WebhookController *controller.WebhookController
+	AdminController   *controller.AdminController
+	SchemaController  *controller.SchemaController
 
 	// External Services
 	GitService    external.GitService
@@ -61,24 +74,56 @@ func InitializeContainer(cfg *config.Config, ctx context.Context) *Container {
 	}
 	container.GitRepository = gitRepo
 
+	// Initialize Job Queue
+	jobQueue, err := queue.NewFileJobQueue(cfg.App.DataDir, cfg.Queue.Capacity, cfg.Queue.HistoryLimit, container.Logger)
+	if err != nil {
+		container.Logger.Error("Failed to initialize Job Queue", "error", err)
+		os.Exit(1)
+	}
+	container.JobQueue = jobQueue
+
 	// Initialize External Services
 	container.GitService = external.NewGitService()
 	container.GitHubService = external.NewGitHubService()
 
+	// Initialize DAG Templates
+	dagTemplates, err := templates.New(cfg.Pipeline.TemplateDir)
+	if err != nil {
+		container.Logger.Error("Failed to initialize DAG templates", "templateDir", cfg.Pipeline.TemplateDir, "error", err)
+		os.Exit(1)
+	}
+
 	// Initialize Usecases
-	container.GenerateAirFlowDAGUsecase = usecase.NewGenerateAirFlowDAGUsecase(container.Logger)
+	container.GenerateAirFlowDAGUsecase = usecase.NewGenerateAirFlowDAGUsecase(
+		templates.NewRegistry(dagTemplates),
+		validator.NewDAGValidators(cfg.Pipeline.ValidationCommand, cfg.Pipeline.ValidationTimeout, container.Logger),
+		container.Logger,
+		cfg)
+	container.PreviewPipelineUsecase = usecase.NewPreviewPipelineUsecase(
+		container.GitRepository,
+		container.GitHubService,
+		container.GenerateAirFlowDAGUsecase,
+		container.Logger,
+		cfg)
 	container.ProcessRepositoryUseCase = usecase.NewProcessPipelineUsecase(
 		container.GitRepository,
 		container.GitHubService,
 		container.GenerateAirFlowDAGUsecase,
+		container.PreviewPipelineUsecase,
+		container.JobQueue,
 		container.Logger,
 		cfg)
+	container.ManageJobsUsecase = usecase.NewManageJobsUsecase(container.JobQueue, container.Logger)
 
 	// Initialize Controllers
 	container.WebhookController = controller.NewWebhookController(container.ProcessRepositoryUseCase, container.Logger, cfg)
+	container.AdminController = controller.NewAdminController(container.ManageJobsUsecase, container.Logger, cfg)
+	container.SchemaController = controller.NewSchemaController(container.Logger, cfg)
 
 	// Start the queue worker in a separate goroutine
+	container.QueueWorkerDone = make(chan struct{})
 	go func() {
+		defer close(container.QueueWorkerDone)
 		container.ProcessRepositoryUseCase.StartQueue(ctx)
 	}()
 
@@ -43,4 +43,12 @@ func main() {
 	container.Logger.Info("Received termination signal. Shutting down gracefully...")
 	cancel()
 
+	// Wait for the worker to stop before closing the journal. A job it was
+	// running is left unsettled on purpose, so it is replayed on the next start
+	<-container.QueueWorkerDone
+
+	if err := container.JobQueue.Close(); err != nil {
+		container.Logger.Error("Failed to close job queue", "error", err)
+	}
+
 }
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/entity"
+	"github.com/Suhaibshah22/pipeweaver/internal/usecase"
+	"github.com/Suhaibshah22/pipeweaver/util"
+)
+
+func runDiff(args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: pipeweaver diff [flags] [pipeline files]\n\nDiffs the DAGs of every pipeline when no files are given.\n\nFlags:")
+		flags.PrintDefaults()
+	}
+	workspaceFlags := addWorkspaceFlags(flags)
+	exitCode := flags.Bool("exit-code", false, "exit with 1 when any DAG is out of date, e.g. to fail CI")
+	flags.Parse(args)
+
+	w, err := workspaceFlags.open()
+	if err != nil {
+		return err
+	}
+	paths, err := w.pipelines(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	failed, changed := false, false
+	for _, path := range paths {
+		dag, err := w.render(path)
+		if err != nil {
+			reportFailure(w.display(path), err)
+			failed = true
+			continue
+		}
+
+		generated := append([]entity.File{{Path: usecase.DAGPathFor(path), Content: dag.Content}}, dag.SQLFiles...)
+		for _, file := range generated {
+			current, err := w.read(file.Path)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				reportFailure(w.display(path), err)
+				failed = true
+				continue
+			}
+
+			diff, err := util.UnifiedDiff(file.Path, current, file.Path, file.Content)
+			if err != nil {
+				reportFailure(w.display(path), err)
+				failed = true
+				continue
+			}
+			if diff != "" {
+				changed = true
+				fmt.Print(diff)
+			}
+		}
+	}
+
+	if failed || (*exitCode && changed) {
+		return errFailed
+	}
+	return nil
+}
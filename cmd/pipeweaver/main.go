@@ -0,0 +1,55 @@
+// Command pipeweaver works with pipeline definitions locally, without running
+// the webhook server.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// errFailed is returned by commands that already reported why they failed.
+var errFailed = errors.New("failed")
+
+const usage = `Usage: pipeweaver <command> [flags]
+
+Commands:
+  render    Render pipeline definitions into Airflow DAGs
+  validate  Check pipeline definitions without rendering them
+  diff      Show how rendering would change the committed DAGs
+  schema    Print the JSON Schema of pipeline definitions
+
+Run "pipeweaver <command> -h" for the flags of a command.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch command, args := os.Args[1], os.Args[2:]; command {
+	case "render":
+		err = runRender(args)
+	case "validate":
+		err = runValidate(args)
+	case "diff":
+		err = runDiff(args)
+	case "schema":
+		err = runSchema(args)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		if !errors.Is(err, errFailed) {
+			fmt.Fprintln(os.Stderr, "pipeweaver:", err)
+		}
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Suhaibshah22/pipeweaver/internal/usecase"
+)
+
+func runRender(args []string) error {
+	flags := flag.NewFlagSet("render", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: pipeweaver render [flags] [pipeline files]\n\nRenders every pipeline when no files are given.\n\nFlags:")
+		flags.PrintDefaults()
+	}
+	workspaceFlags := addWorkspaceFlags(flags)
+	write := flags.Bool("w", false, "write DAGs to "+usecase.OUTPUT_DIRECTORY+" instead of stdout")
+	flags.Parse(args)
+
+	w, err := workspaceFlags.open()
+	if err != nil {
+		return err
+	}
+	paths, err := w.pipelines(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	failed := false
+	for _, path := range paths {
+		dag, err := w.render(path)
+		if err != nil {
+			reportFailure(w.display(path), err)
+			failed = true
+			continue
+		}
+
+		if !*write {
+			if len(paths) > 1 {
+				fmt.Printf("# %s\n", usecase.DAGPathFor(path))
+			}
+			os.Stdout.Write(dag.Content)
+			continue
+		}
+
+		dagPath := usecase.DAGPathFor(path)
+		if err := w.write(dagPath, dag.Content); err != nil {
+			reportFailure(w.display(path), fmt.Errorf("failed to write %s: %w", dagPath, err))
+			failed = true
+			continue
+		}
+		if err := w.writeSQLFiles(path, dag.SQLFiles); err != nil {
+			reportFailure(w.display(path), fmt.Errorf("failed to write SQL files: %w", err))
+			failed = true
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "%s -> %s\n", w.display(path), w.display(dagPath))
+	}
+
+	if failed {
+		return errFailed
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/schema"
+)
+
+func runSchema(args []string) error {
+	flags := flag.NewFlagSet("schema", flag.ExitOnError)
+	version := flags.String("version", schema.Versions[len(schema.Versions)-1], "pipeline version to print the schema of")
+	output := flags.String("o", "", "write the schema to this file instead of stdout")
+	flags.Parse(args)
+
+	s, err := schema.For(*version)
+	if err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode schema: %w", err)
+	}
+	content = append(content, '\n')
+
+	if *output == "" {
+		_, err = os.Stdout.Write(content)
+		return err
+	}
+	if err := os.WriteFile(*output, content, 0644); err != nil {
+		return fmt.Errorf("failed to write schema: %w", err)
+	}
+	return nil
+}
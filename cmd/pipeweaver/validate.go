@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// runValidate renders every pipeline without writing anything, so a pipeline
+// only passes when its DAG would be generated.
+func runValidate(args []string) error {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: pipeweaver validate [flags] [pipeline files]\n\nValidates every pipeline when no files are given.\n\nFlags:")
+		flags.PrintDefaults()
+	}
+	workspaceFlags := addWorkspaceFlags(flags)
+	flags.Parse(args)
+
+	w, err := workspaceFlags.open()
+	if err != nil {
+		return err
+	}
+	paths, err := w.pipelines(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, path := range paths {
+		content, err := w.read(path)
+		if err != nil {
+			reportFailure(w.display(path), err)
+			failed++
+			continue
+		}
+
+		// Generating validates the definition first, and a valid definition
+		// can still fail to render, e.g. with a step config no operator takes
+		dag, err := w.generate(path, content)
+		if err != nil {
+			reportFailure(w.display(path), err)
+			failed++
+			continue
+		}
+		reportWarnings(w.display(path), dag)
+	}
+
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d of %d pipeline(s) are invalid\n", failed, len(paths))
+		return errFailed
+	}
+	return nil
+}
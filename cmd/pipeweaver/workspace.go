@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Suhaibshah22/pipeweaver/cmd/config"
+	"github.com/Suhaibshah22/pipeweaver/internal/adapter/validator"
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/entity"
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/validation"
+	"github.com/Suhaibshah22/pipeweaver/internal/usecase"
+	"github.com/Suhaibshah22/pipeweaver/internal/usecase/templates"
+)
+
+// workspace is a local checkout of a pipelines repository. Nothing is pushed
+// and GitHub is never called, everything happens on the files in Root.
+type workspace struct {
+	Root      string
+	Config    *config.Config
+	Log       *slog.Logger
+	Generator usecase.GenerateAirFlowDAGUsecase
+}
+
+// workspaceFlags registers the flags shared by the commands working on a checkout.
+type workspaceFlags struct {
+	root    *string
+	verbose *bool
+}
+
+func addWorkspaceFlags(flags *flag.FlagSet) workspaceFlags {
+	return workspaceFlags{
+		root:    flags.String("C", ".", "root of the pipelines repository"),
+		verbose: flags.Bool("v", false, "log what is being done to stderr"),
+	}
+}
+
+func (f workspaceFlags) open() (*workspace, error) {
+	// Logs would end up mixed into rendered DAGs, so they go to stderr and
+	// only when asked for
+	output := io.Discard
+	if *f.verbose {
+		output = os.Stderr
+	}
+	log.SetOutput(output)
+	logger := slog.New(slog.NewTextHandler(output, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	dagTemplates, err := templates.New(cfg.Pipeline.TemplateDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load DAG templates: %w", err)
+	}
+
+	generator := usecase.NewGenerateAirFlowDAGUsecase(
+		templates.NewRegistry(dagTemplates),
+		validator.NewDAGValidators(cfg.Pipeline.ValidationCommand, cfg.Pipeline.ValidationTimeout, logger),
+		logger,
+		cfg)
+
+	return &workspace{
+		Root:      *f.root,
+		Config:    cfg,
+		Log:       logger,
+		Generator: generator,
+	}, nil
+}
+
+// pipelines resolves the pipeline files given on the command line to paths
+// relative to the repository root, as they would appear in a push. Every
+// pipeline in the repository is returned when none are given.
+func (w *workspace) pipelines(args []string) ([]string, error) {
+	if len(args) == 0 {
+		return w.allPipelines()
+	}
+
+	root, err := filepath.Abs(w.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(args))
+	for _, arg := range args {
+		// Like git -C, arguments are relative to the repository root
+		absolute := arg
+		if !filepath.IsAbs(absolute) {
+			absolute = filepath.Join(root, arg)
+		}
+
+		path, err := filepath.Rel(root, absolute)
+		if err != nil || strings.HasPrefix(path, "..") {
+			return nil, fmt.Errorf("%s is outside of the repository %s", arg, w.Root)
+		}
+		path = filepath.ToSlash(path)
+		if !strings.HasPrefix(path, usecase.PIPELINES_DIRECTORY) {
+			return nil, fmt.Errorf("%s is not in the %s directory", arg, usecase.PIPELINES_DIRECTORY)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func (w *workspace) allPipelines() ([]string, error) {
+	var paths []string
+	directory := filepath.Join(w.Root, usecase.PIPELINES_DIRECTORY)
+	err := filepath.WalkDir(directory, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		switch filepath.Ext(path) {
+		case ".yaml", ".yml":
+			relative, err := filepath.Rel(w.Root, path)
+			if err != nil {
+				return err
+			}
+			paths = append(paths, filepath.ToSlash(relative))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pipelines: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no pipelines found in %s", directory)
+	}
+	return paths, nil
+}
+
+// display returns how a path relative to the repository root is shown to the
+// user.
+func (w *workspace) display(path string) string {
+	return filepath.Join(w.Root, filepath.FromSlash(path))
+}
+
+// read returns the content of a file relative to the repository root.
+func (w *workspace) read(path string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(w.Root, filepath.FromSlash(path)))
+}
+
+// write replaces the content of a file relative to the repository root.
+func (w *workspace) write(path string, content []byte) error {
+	absolute := filepath.Join(w.Root, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(absolute), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(absolute, content, 0644)
+}
+
+// writeSQLFiles replaces the SQL files copied for a pipeline's steps.
+func (w *workspace) writeSQLFiles(path string, files []entity.File) error {
+	// Subdirectories belong to pipelines in a directory named like this one
+	sqlDir := filepath.Join(w.Root, filepath.FromSlash(usecase.SQLDirFor(path)))
+	stale, err := filepath.Glob(filepath.Join(sqlDir, "*.sql"))
+	if err != nil {
+		return err
+	}
+	for _, file := range stale {
+		if err := os.Remove(file); err != nil {
+			return err
+		}
+	}
+
+	for _, file := range files {
+		if err := w.write(file.Path, file.Content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// render generates the DAG of one pipeline, printing its warnings to stderr.
+func (w *workspace) render(path string) (*usecase.GeneratedDAG, error) {
+	content, err := w.read(path)
+	if err != nil {
+		return nil, err
+	}
+	dag, err := w.generate(path, content)
+	if err != nil {
+		return nil, err
+	}
+	reportWarnings(w.display(path), dag)
+	return dag, nil
+}
+
+// reportWarnings prints the warnings of a generated DAG, those of the
+// definition with the line and column they were found at.
+func reportWarnings(path string, dag *usecase.GeneratedDAG) {
+	for _, warning := range dag.DefinitionWarnings {
+		fmt.Fprintln(os.Stderr, formatProblem(path, warning, "warning"))
+	}
+	for _, warning := range dag.Warnings {
+		fmt.Fprintf(os.Stderr, "%s: warning: %s\n", path, warning)
+	}
+}
+
+// generate runs the generator on the content of a pipeline, reading its SQL
+// files from the checkout.
+func (w *workspace) generate(path string, content []byte) (*usecase.GeneratedDAG, error) {
+	readFile := func(ctx context.Context, path string) ([]byte, error) {
+		return w.read(path)
+	}
+	return w.Generator.Execute(context.Background(), content, path, readFile)
+}
+
+// reportFailure prints why a pipeline failed, one problem per line.
+func reportFailure(path string, err error) {
+	var problems validation.Errors
+	if errors.As(err, &problems) {
+		for _, problem := range problems {
+			fmt.Fprintln(os.Stderr, formatProblem(path, problem, "error"))
+		}
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s: error: %s\n", path, err)
+}
+
+// formatProblem prints a problem the way compilers do (file:line:column:), so
+// editors and CI annotations can link to it.
+func formatProblem(path string, problem validation.Problem, severity string) string {
+	location := path
+	if problem.Line > 0 {
+		location += fmt.Sprintf(":%d", problem.Line)
+		if problem.Column > 0 {
+			location += fmt.Sprintf(":%d", problem.Column)
+		}
+	}
+
+	message := problem.Message
+	if problem.Path != "" {
+		message = problem.Path + ": " + message
+	}
+	return fmt.Sprintf("%s: %s: %s", location, severity, message)
+}
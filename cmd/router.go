@@ -13,5 +13,25 @@ func SetupRouter(container *Container) *gin.Engine {
 		webhookGroup.POST("/git", container.WebhookController.HandleWebhook)
 	}
 
+	// Schema Routes
+	schemaGroup := router.Group("/schemas")
+	{
+		schemaGroup.GET("/pipeline/:version", container.SchemaController.GetPipelineSchema)
+	}
+
+	// Job Routes
+	jobsGroup := router.Group("/jobs", container.AdminController.Authorize)
+	{
+		jobsGroup.GET("", container.AdminController.ListJobs)
+		jobsGroup.GET("/:id", container.AdminController.GetJob)
+	}
+
+	// Admin Routes
+	adminGroup := router.Group("/admin", container.AdminController.Authorize)
+	{
+		adminGroup.GET("/dead-letters", container.AdminController.ListDeadLetters)
+		adminGroup.POST("/dead-letters/:id/redrive", container.AdminController.RedriveDeadLetter)
+	}
+
 	return router
 }
@@ -2,6 +2,11 @@ package external
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Suhaibshah22/pipeweaver/util"
 
 	"github.com/google/go-github/v50/github"
 	"golang.org/x/oauth2"
@@ -9,6 +14,12 @@ import (
 
 type GitHubService interface {
 	CreatePullRequest(owner, repo, title, head, base, body, token string) (*github.PullRequest, error)
+	FindPullRequest(owner, repo, head, base, token string) (*github.PullRequest, error)
+	UpdatePullRequest(owner, repo string, number int, title, body, token string) (*github.PullRequest, error)
+	ListPullRequestFiles(owner, repo string, number int, token string) ([]*github.CommitFile, error)
+	FindIssueComment(owner, repo string, number int, marker, token string) (*github.IssueComment, error)
+	CreateIssueComment(owner, repo string, number int, body, token string) (*github.IssueComment, error)
+	EditIssueComment(owner, repo string, commentID int64, body, token string) (*github.IssueComment, error)
 }
 
 type gitHubService struct{}
@@ -17,14 +28,18 @@ func NewGitHubService() GitHubService {
 	return &gitHubService{}
 }
 
-func (p *gitHubService) CreatePullRequest(owner, repo, title, head, base, body, token string) (*github.PullRequest, error) {
-	ctx := context.Background()
+func newClient(ctx context.Context, token string) *github.Client {
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
 	)
 	tc := oauth2.NewClient(ctx, ts)
 
-	client := github.NewClient(tc)
+	return github.NewClient(tc)
+}
+
+func (p *gitHubService) CreatePullRequest(owner, repo, title, head, base, body, token string) (*github.PullRequest, error) {
+	ctx := context.Background()
+	client := newClient(ctx, token)
 
 	newPR := &github.NewPullRequest{
 		Title: github.String(title),
@@ -35,31 +50,139 @@ func (p *gitHubService) CreatePullRequest(owner, repo, title, head, base, body,
 
 	pr, _, err := client.PullRequests.Create(ctx, owner, repo, newPR)
 	if err != nil {
-		return nil, err
+		return nil, classifyGitHubError(err)
 	}
 
 	return pr, nil
 }
 
-type GitHubWebhookPayload struct {
-	Ref        string `json:"ref"`
-	Before     string `json:"before"`
-	After      string `json:"after"`
-	Repository struct {
-		Name  string `json:"name"`
-		Owner struct {
-			Login string `json:"login"`
-		} `json:"owner"`
-		CloneURL string `json:"clone_url"`
-	} `json:"repository"`
-	Commits []struct {
-		ID       string   `json:"id"`
-		Message  string   `json:"message"`
-		Modified []string `json:"modified"`
-	} `json:"commits"`
-	HeadCommit struct {
-		ID       string   `json:"id"`
-		Message  string   `json:"message"`
-		Modified []string `json:"modified"`
-	} `json:"head_commit"`
+// FindPullRequest returns the open pull request from the head branch into base,
+// or nil if there is none.
+func (p *gitHubService) FindPullRequest(owner, repo, head, base, token string) (*github.PullRequest, error) {
+	ctx := context.Background()
+	client := newClient(ctx, token)
+
+	prs, _, err := client.PullRequests.List(ctx, owner, repo, &github.PullRequestListOptions{
+		State: "open",
+		Head:  owner + ":" + head,
+		Base:  base,
+	})
+	if err != nil {
+		return nil, classifyGitHubError(err)
+	}
+	if len(prs) == 0 {
+		return nil, nil
+	}
+
+	return prs[0], nil
+}
+
+func (p *gitHubService) UpdatePullRequest(owner, repo string, number int, title, body, token string) (*github.PullRequest, error) {
+	ctx := context.Background()
+	client := newClient(ctx, token)
+
+	pr, _, err := client.PullRequests.Edit(ctx, owner, repo, number, &github.PullRequest{
+		Title: github.String(title),
+		Body:  github.String(body),
+	})
+	if err != nil {
+		return nil, classifyGitHubError(err)
+	}
+
+	return pr, nil
+}
+
+// ListPullRequestFiles returns every file changed by a pull request, following pagination.
+func (p *gitHubService) ListPullRequestFiles(owner, repo string, number int, token string) ([]*github.CommitFile, error) {
+	ctx := context.Background()
+	client := newClient(ctx, token)
+
+	var files []*github.CommitFile
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := client.PullRequests.ListFiles(ctx, owner, repo, number, opts)
+		if err != nil {
+			return nil, classifyGitHubError(err)
+		}
+		files = append(files, page...)
+
+		if resp.NextPage == 0 {
+			return files, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// FindIssueComment returns the first comment on an issue or pull request whose
+// body contains marker, or nil if there is none.
+func (p *gitHubService) FindIssueComment(owner, repo string, number int, marker, token string) (*github.IssueComment, error) {
+	ctx := context.Background()
+	client := newClient(ctx, token)
+
+	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		comments, resp, err := client.Issues.ListComments(ctx, owner, repo, number, opts)
+		if err != nil {
+			return nil, classifyGitHubError(err)
+		}
+		for _, comment := range comments {
+			if strings.Contains(comment.GetBody(), marker) {
+				return comment, nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+func (p *gitHubService) CreateIssueComment(owner, repo string, number int, body, token string) (*github.IssueComment, error) {
+	ctx := context.Background()
+	client := newClient(ctx, token)
+
+	comment, _, err := client.Issues.CreateComment(ctx, owner, repo, number, &github.IssueComment{
+		Body: github.String(body),
+	})
+	if err != nil {
+		return nil, classifyGitHubError(err)
+	}
+
+	return comment, nil
+}
+
+func (p *gitHubService) EditIssueComment(owner, repo string, commentID int64, body, token string) (*github.IssueComment, error) {
+	ctx := context.Background()
+	client := newClient(ctx, token)
+
+	comment, _, err := client.Issues.EditComment(ctx, owner, repo, commentID, &github.IssueComment{
+		Body: github.String(body),
+	})
+	if err != nil {
+		return nil, classifyGitHubError(err)
+	}
+
+	return comment, nil
+}
+
+// classifyGitHubError marks rate limits, server errors and network failures as
+// retryable. Other API errors (validation, permissions, not found) are permanent.
+func classifyGitHubError(err error) error {
+	var rateLimit *github.RateLimitError
+	var abuseRateLimit *github.AbuseRateLimitError
+	var response *github.ErrorResponse
+
+	switch {
+	case errors.As(err, &rateLimit), errors.As(err, &abuseRateLimit):
+		return util.Retryable(err)
+	case errors.As(err, &response):
+		if response.Response != nil &&
+			(response.Response.StatusCode >= http.StatusInternalServerError || response.Response.StatusCode == http.StatusTooManyRequests) {
+			return util.Retryable(err)
+		}
+		return err
+	default:
+		return util.Retryable(err)
+	}
 }
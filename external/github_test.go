@@ -0,0 +1,47 @@
+package external
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Suhaibshah22/pipeweaver/util"
+
+	"github.com/google/go-github/v50/github"
+)
+
+func TestClassifyGitHubError(t *testing.T) {
+	response := func(status int) error {
+		return &github.ErrorResponse{Response: &http.Response{StatusCode: status}}
+	}
+
+	tests := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{"rate limit", &github.RateLimitError{}, true},
+		{"secondary rate limit", &github.AbuseRateLimitError{}, true},
+		{"server error", response(http.StatusBadGateway), true},
+		{"too many requests", response(http.StatusTooManyRequests), true},
+		{"wrapped server error", fmt.Errorf("create pull request: %w", response(http.StatusServiceUnavailable)), true},
+		{"network error", errors.New("dial tcp: connection refused"), true},
+		{"unauthorized", response(http.StatusUnauthorized), false},
+		{"not found", response(http.StatusNotFound), false},
+		{"validation failed", response(http.StatusUnprocessableEntity), false},
+		{"response without status", &github.ErrorResponse{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classifyGitHubError(tt.err)
+			if got := util.IsRetryable(err); got != tt.retryable {
+				t.Errorf("IsRetryable(classifyGitHubError(%v)) = %t, want %t", tt.err, got, tt.retryable)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("classifyGitHubError(%v) = %v, want it to wrap the error", tt.err, err)
+			}
+		})
+	}
+}
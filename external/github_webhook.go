@@ -0,0 +1,124 @@
+package external
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// GitHub sends the HMAC-SHA256 of the raw request body in this header,
+// prefixed with "sha256=".
+const GitHubSignatureHeader = "X-Hub-Signature-256"
+
+const signaturePrefix = "sha256="
+
+var (
+	ErrMissingSignature = errors.New("missing webhook signature")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrNoWebhookSecrets = errors.New("no webhook secrets configured")
+)
+
+// VerifyGitHubSignature checks the X-Hub-Signature-256 header value against the
+// raw request body. Any of the given secrets may have signed the body, which
+// allows the secret to be rotated without dropping deliveries.
+func VerifyGitHubSignature(body []byte, signature string, secrets []string) error {
+	if len(secrets) == 0 {
+		return ErrNoWebhookSecrets
+	}
+	if signature == "" {
+		return ErrMissingSignature
+	}
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return ErrInvalidSignature
+	}
+
+	expected, err := hex.DecodeString(strings.TrimPrefix(signature, signaturePrefix))
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	for _, secret := range secrets {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		if hmac.Equal(mac.Sum(nil), expected) {
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
+
+// Headers GitHub sets on every webhook delivery.
+const (
+	GitHubEventHeader    = "X-GitHub-Event"
+	GitHubDeliveryHeader = "X-GitHub-Delivery"
+)
+
+// Webhook event types pipeweaver understands.
+const (
+	GitHubEventPing        = "ping"
+	GitHubEventPush        = "push"
+	GitHubEventPullRequest = "pull_request"
+)
+
+// Pull request actions that trigger a DAG preview.
+const (
+	PullRequestActionOpened      = "opened"
+	PullRequestActionReopened    = "reopened"
+	PullRequestActionSynchronize = "synchronize"
+)
+
+// GitHubRepository is the repository a webhook delivery originated from.
+type GitHubRepository struct {
+	Name  string `json:"name"`
+	Owner struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+	CloneURL string `json:"clone_url"`
+}
+
+// GitHubPingPayload is sent once when a webhook is created.
+type GitHubPingPayload struct {
+	Zen    string `json:"zen"`
+	HookID int64  `json:"hook_id"`
+}
+
+// GitHubPushPayload is sent when commits are pushed to a branch.
+type GitHubPushPayload struct {
+	Ref        string           `json:"ref"`
+	Before     string           `json:"before"`
+	After      string           `json:"after"`
+	Repository GitHubRepository `json:"repository"`
+	Commits    []GitHubCommit   `json:"commits"`
+	HeadCommit GitHubCommit     `json:"head_commit"`
+}
+
+// GitHubCommit is a commit summary included in a push payload. GitHub caps the
+// number of commits in a payload, so the file lists may be incomplete for large pushes.
+type GitHubCommit struct {
+	ID       string   `json:"id"`
+	Message  string   `json:"message"`
+	Added    []string `json:"added"`
+	Removed  []string `json:"removed"`
+	Modified []string `json:"modified"`
+}
+
+// GitHubPullRequestPayload is sent when a pull request is opened, updated or closed.
+type GitHubPullRequestPayload struct {
+	Action      string `json:"action"`
+	Number      int    `json:"number"`
+	PullRequest struct {
+		HTMLURL string `json:"html_url"`
+		Head    struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		} `json:"base"`
+	} `json:"pull_request"`
+	Repository GitHubRepository `json:"repository"`
+}
@@ -0,0 +1,626 @@
+package queue
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/entity"
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/port/queue"
+)
+
+// journalFile is the append-only log of job snapshots kept in the data directory.
+// Each line is the latest state of one job, later lines win over earlier ones.
+const journalFile = "jobs.journal"
+
+// The journal is compacted once it holds this many stale records.
+const compactThreshold = 1000
+
+// forgottenFile keeps the dedup keys of jobs pruned from the journal, as a JSON
+// array of the jobs without their payloads and results, oldest first.
+const forgottenFile = "jobs.forgotten.json"
+
+// forgottenLimit is how many dedup keys of pruned jobs are remembered, on top
+// of those of the retained jobs.
+const forgottenLimit = 10000
+
+type fileJobQueue struct {
+	mu           sync.Mutex
+	file         *os.File
+	path         string
+	capacity     int
+	historyLimit int
+	records      int
+
+	jobs    map[string]*entity.Job // retained jobs by ID
+	pending []string               // IDs waiting to be dequeued, oldest first
+
+	// forgotten are pruned jobs by dedup key, so a redelivered push that is
+	// older than the history is still recognised. Only their ID, dedup key,
+	// status and timestamps are kept
+	forgotten     map[string]*entity.Job
+	forgottenKeys []string // oldest first
+	forgottenPath string
+
+	notify chan struct{}
+	done   chan struct{}
+	closed bool
+
+	Log *slog.Logger
+}
+
+// NewFileJobQueue opens (or creates) the job journal in dir. Jobs that were
+// queued or running when the process stopped are queued again. Only the newest
+// historyLimit succeeded or skipped jobs are kept, failed jobs are kept until
+// they are redriven.
+func NewFileJobQueue(dir string, capacity int, historyLimit int, logger *slog.Logger) (queue.JobQueue, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("failed to create queue directory %s: %w", dir, err)
+	}
+
+	q := &fileJobQueue{
+		path:          filepath.Join(dir, journalFile),
+		capacity:      capacity,
+		historyLimit:  historyLimit,
+		jobs:          map[string]*entity.Job{},
+		forgotten:     map[string]*entity.Job{},
+		forgottenPath: filepath.Join(dir, forgottenFile),
+		notify:        make(chan struct{}, 1),
+		done:          make(chan struct{}),
+		Log:           logger,
+	}
+
+	if err := q.loadForgotten(); err != nil {
+		return nil, err
+	}
+	if err := q.replay(); err != nil {
+		return nil, err
+	}
+	if err := q.compact(); err != nil {
+		return nil, err
+	}
+
+	if len(q.pending) > 0 {
+		q.Log.Info("Replaying unfinished jobs", "count", len(q.pending), "journal", q.path)
+	}
+	return q, nil
+}
+
+// Enqueue implements queue.JobQueue.
+func (q *fileJobQueue) Enqueue(ctx context.Context, job *entity.Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return queue.ErrQueueClosed
+	}
+	if _, ok := q.jobs[job.ID]; ok {
+		return fmt.Errorf("%s: %w", job.ID, queue.ErrJobExists)
+	}
+	if existing := q.byDedupKey(job.DedupKey); existing != nil {
+		return fmt.Errorf("%s duplicates job %s: %w", job.ID, existing.ID, queue.ErrJobExists)
+	}
+	if q.capacity > 0 && q.activeJobs() >= q.capacity {
+		return queue.ErrQueueFull
+	}
+
+	now := time.Now().UTC()
+	stored := *job
+	stored.Status = entity.JobQueued
+	stored.CreatedAt = now
+	stored.UpdatedAt = now
+
+	if err := q.append(&stored); err != nil {
+		return err
+	}
+	q.jobs[stored.ID] = &stored
+	q.pending = append(q.pending, stored.ID)
+	*job = stored
+
+	q.wake()
+	return nil
+}
+
+// Dequeue implements queue.JobQueue.
+func (q *fileJobQueue) Dequeue(ctx context.Context) (*entity.Job, error) {
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return nil, queue.ErrQueueClosed
+		}
+
+		index, wait := q.nextReady(time.Now().UTC())
+		if index >= 0 {
+			job := q.jobs[q.pending[index]]
+			job.Status = entity.JobRunning
+			job.Attempts++
+			job.UpdatedAt = time.Now().UTC()
+			if err := q.append(job); err != nil {
+				job.Status = entity.JobQueued
+				job.Attempts--
+				q.mu.Unlock()
+				return nil, err
+			}
+			q.pending = append(q.pending[:index], q.pending[index+1:]...)
+
+			dequeued := *job
+			q.mu.Unlock()
+			return &dequeued, nil
+		}
+		q.mu.Unlock()
+
+		// Sleep until a job is enqueued or the next retry is due
+		var timer *time.Timer
+		var due <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			due = timer.C
+		}
+
+		select {
+		case <-q.notify:
+		case <-due:
+		case <-q.done:
+		case <-ctx.Done():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Ack implements queue.JobQueue.
+func (q *fileJobQueue) Ack(ctx context.Context, id string, status entity.JobStatus, result *entity.JobResult) error {
+	if status != entity.JobSucceeded && status != entity.JobSkipped {
+		return fmt.Errorf("cannot acknowledge job %s as %s", id, status)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, err := q.runningJob(id)
+	if err != nil {
+		return err
+	}
+
+	job.Status = status
+	job.Result = result
+	job.NextAttemptAt = time.Time{}
+	job.UpdatedAt = time.Now().UTC()
+	if err := q.append(job); err != nil {
+		return err
+	}
+
+	q.prune()
+	if q.records-len(q.jobs) > compactThreshold {
+		return q.compact()
+	}
+	return nil
+}
+
+// Retry implements queue.JobQueue.
+func (q *fileJobQueue) Retry(ctx context.Context, id string, at time.Time, reason string, result *entity.JobResult) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, err := q.runningJob(id)
+	if err != nil {
+		return err
+	}
+
+	job.Status = entity.JobQueued
+	job.Result = result
+	job.NextAttemptAt = at.UTC()
+	job.LastError = reason
+	job.UpdatedAt = time.Now().UTC()
+	if err := q.append(job); err != nil {
+		return err
+	}
+	q.pending = append(q.pending, id)
+
+	q.wake()
+	return nil
+}
+
+// DeadLetter implements queue.JobQueue.
+func (q *fileJobQueue) DeadLetter(ctx context.Context, id string, reason string, result *entity.JobResult) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, err := q.runningJob(id)
+	if err != nil {
+		return err
+	}
+
+	job.Status = entity.JobFailed
+	job.Result = result
+	job.NextAttemptAt = time.Time{}
+	job.LastError = reason
+	job.UpdatedAt = time.Now().UTC()
+	return q.append(job)
+}
+
+// Redrive implements queue.JobQueue.
+func (q *fileJobQueue) Redrive(ctx context.Context, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return queue.ErrQueueClosed
+	}
+
+	job, ok := q.jobs[id]
+	if !ok || job.Status != entity.JobFailed {
+		return fmt.Errorf("failed job %s: %w", id, queue.ErrJobNotFound)
+	}
+
+	job.Status = entity.JobQueued
+	job.Attempts = 0
+	job.NextAttemptAt = time.Time{}
+	job.UpdatedAt = time.Now().UTC()
+	if err := q.append(job); err != nil {
+		job.Status = entity.JobFailed
+		return err
+	}
+	q.pending = append(q.pending, id)
+
+	q.wake()
+	return nil
+}
+
+// Find implements queue.JobQueue.
+func (q *fileJobQueue) Find(ctx context.Context, id string) (*entity.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", id, queue.ErrJobNotFound)
+	}
+
+	found := *job
+	return &found, nil
+}
+
+// FindByDedupKey implements queue.JobQueue.
+func (q *fileJobQueue) FindByDedupKey(ctx context.Context, key string) (*entity.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job := q.byDedupKey(key)
+	if job == nil {
+		return nil, fmt.Errorf("dedup key %s: %w", key, queue.ErrJobNotFound)
+	}
+
+	found := *job
+	return &found, nil
+}
+
+// List implements queue.JobQueue.
+func (q *fileJobQueue) List(ctx context.Context, status entity.JobStatus, limit int) ([]*entity.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	ids := q.orderedIDs()
+	jobs := []*entity.Job{}
+	for i := len(ids) - 1; i >= 0; i-- {
+		job := q.jobs[ids[i]]
+		if status != "" && job.Status != status {
+			continue
+		}
+
+		listed := *job
+		jobs = append(jobs, &listed)
+		if limit > 0 && len(jobs) == limit {
+			break
+		}
+	}
+	return jobs, nil
+}
+
+// Close implements queue.JobQueue.
+func (q *fileJobQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	close(q.done)
+
+	return q.file.Close()
+}
+
+// replay rebuilds the retained jobs from the journal. A torn final line left by
+// a crash mid-write is skipped.
+func (q *fileJobQueue) replay() error {
+	file, err := os.Open(q.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open job journal %s: %w", q.path, err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var job entity.Job
+			if jsonErr := json.Unmarshal(line, &job); jsonErr != nil || job.ID == "" {
+				q.Log.Warn("Skipping unreadable job record", "journal", q.path, "line", lineNumber, "error", jsonErr)
+			} else {
+				q.jobs[job.ID] = &job
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read job journal %s: %w", q.path, err)
+		}
+	}
+
+	// Jobs that were running when we stopped were never settled, run them again
+	for _, id := range q.orderedIDs() {
+		job := q.jobs[id]
+		if job.Status.Finished() {
+			continue
+		}
+		job.Status = entity.JobQueued
+		q.pending = append(q.pending, id)
+	}
+
+	q.prune()
+	return nil
+}
+
+// compact rewrites the journal with only the retained jobs and reopens it for appending.
+func (q *fileJobQueue) compact() error {
+	tmpPath := q.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create job journal %s: %w", tmpPath, err)
+	}
+
+	writer := bufio.NewWriter(tmp)
+	for _, id := range q.orderedIDs() {
+		data, err := json.Marshal(q.jobs[id])
+		if err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to encode job %s: %w", id, err)
+		}
+		writer.Write(append(data, '\n'))
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write job journal %s: %w", tmpPath, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync job journal %s: %w", tmpPath, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close job journal %s: %w", tmpPath, err)
+	}
+
+	// The dedup keys of pruned jobs are saved before their records are dropped
+	if err := q.saveForgotten(); err != nil {
+		return err
+	}
+
+	if q.file != nil {
+		q.file.Close()
+	}
+	if err := os.Rename(tmpPath, q.path); err != nil {
+		return fmt.Errorf("failed to replace job journal %s: %w", q.path, err)
+	}
+
+	q.file, err = os.OpenFile(q.path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open job journal %s: %w", q.path, err)
+	}
+	q.records = len(q.jobs)
+	return nil
+}
+
+// append durably records the latest state of a job.
+func (q *fileJobQueue) append(job *entity.Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to encode job %s: %w", job.ID, err)
+	}
+
+	if _, err := q.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write job %s: %w", job.ID, err)
+	}
+	if err := q.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync job %s: %w", job.ID, err)
+	}
+
+	q.records++
+	return nil
+}
+
+// prune forgets the oldest succeeded and skipped jobs beyond the history limit.
+// Their records are dropped from the journal on the next compaction, only
+// their dedup keys are remembered.
+func (q *fileJobQueue) prune() {
+	var settled []string
+	for _, id := range q.orderedIDs() {
+		if status := q.jobs[id].Status; status == entity.JobSucceeded || status == entity.JobSkipped {
+			settled = append(settled, id)
+		}
+	}
+
+	for len(settled) > q.historyLimit {
+		q.forget(q.jobs[settled[0]])
+		delete(q.jobs, settled[0])
+		settled = settled[1:]
+	}
+}
+
+// forget remembers the dedup key of a pruned job, forgetting the oldest keys
+// beyond forgottenLimit.
+func (q *fileJobQueue) forget(job *entity.Job) {
+	if job.DedupKey == "" {
+		return
+	}
+	if _, ok := q.forgotten[job.DedupKey]; !ok {
+		q.forgottenKeys = append(q.forgottenKeys, job.DedupKey)
+	}
+	q.forgotten[job.DedupKey] = &entity.Job{
+		ID:         job.ID,
+		DeliveryID: job.DeliveryID,
+		Event:      job.Event,
+		Status:     job.Status,
+		DedupKey:   job.DedupKey,
+		Attempts:   job.Attempts,
+		CreatedAt:  job.CreatedAt,
+		UpdatedAt:  job.UpdatedAt,
+	}
+
+	for len(q.forgottenKeys) > forgottenLimit {
+		delete(q.forgotten, q.forgottenKeys[0])
+		q.forgottenKeys = q.forgottenKeys[1:]
+	}
+}
+
+// loadForgotten reads the dedup keys of the jobs pruned by earlier compactions.
+func (q *fileJobQueue) loadForgotten() error {
+	data, err := os.ReadFile(q.forgottenPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read forgotten jobs %s: %w", q.forgottenPath, err)
+	}
+
+	var jobs []*entity.Job
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		// Losing them only lets old pushes run again, it must not stop the queue
+		q.Log.Warn("Skipping unreadable forgotten jobs", "path", q.forgottenPath, "error", err)
+		return nil
+	}
+	for _, job := range jobs {
+		q.forget(job)
+	}
+	return nil
+}
+
+// saveForgotten replaces the file of forgotten jobs.
+func (q *fileJobQueue) saveForgotten() error {
+	jobs := make([]*entity.Job, len(q.forgottenKeys))
+	for i, key := range q.forgottenKeys {
+		jobs[i] = q.forgotten[key]
+	}
+	data, err := json.Marshal(jobs)
+	if err != nil {
+		return fmt.Errorf("failed to encode forgotten jobs: %w", err)
+	}
+
+	tmpPath := q.forgottenPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write forgotten jobs %s: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, q.forgottenPath); err != nil {
+		return fmt.Errorf("failed to replace forgotten jobs %s: %w", q.forgottenPath, err)
+	}
+	return nil
+}
+
+// wake lets a waiting Dequeue know the pending jobs changed.
+func (q *fileJobQueue) wake() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// orderedIDs returns the IDs of the retained jobs, oldest first.
+func (q *fileJobQueue) orderedIDs() []string {
+	ids := make([]string, 0, len(q.jobs))
+	for id := range q.jobs {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return q.jobs[ids[i]].CreatedAt.Before(q.jobs[ids[j]].CreatedAt)
+	})
+	return ids
+}
+
+// byDedupKey returns the newest retained job with the given dedup key, or
+// what is remembered of a pruned one, or nil.
+func (q *fileJobQueue) byDedupKey(key string) *entity.Job {
+	if key == "" {
+		return nil
+	}
+
+	var newest *entity.Job
+	for _, job := range q.jobs {
+		if job.DedupKey == key && (newest == nil || job.CreatedAt.After(newest.CreatedAt)) {
+			newest = job
+		}
+	}
+	if newest == nil {
+		newest = q.forgotten[key]
+	}
+	return newest
+}
+
+// nextReady returns the index in pending of the oldest job that is due, or -1
+// and how long until the next retry is due (0 if nothing is scheduled).
+func (q *fileJobQueue) nextReady(now time.Time) (int, time.Duration) {
+	var wait time.Duration
+	for i, id := range q.pending {
+		due := q.jobs[id].NextAttemptAt
+		if !due.After(now) {
+			return i, 0
+		}
+		if until := due.Sub(now); wait == 0 || until < wait {
+			wait = until
+		}
+	}
+	return -1, wait
+}
+
+// runningJob returns a job that has been dequeued and not yet settled.
+func (q *fileJobQueue) runningJob(id string) (*entity.Job, error) {
+	if q.closed {
+		return nil, queue.ErrQueueClosed
+	}
+
+	job, ok := q.jobs[id]
+	if !ok || job.Status != entity.JobRunning {
+		return nil, fmt.Errorf("running job %s: %w", id, queue.ErrJobNotFound)
+	}
+	return job, nil
+}
+
+// activeJobs counts the jobs still waiting for or undergoing processing.
+func (q *fileJobQueue) activeJobs() int {
+	count := 0
+	for _, job := range q.jobs {
+		if !job.Status.Finished() {
+			count++
+		}
+	}
+	return count
+}
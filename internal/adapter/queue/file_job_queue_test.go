@@ -0,0 +1,263 @@
+package queue
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/entity"
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/port/queue"
+)
+
+func openQueue(t *testing.T, dir string, historyLimit int) queue.JobQueue {
+	t.Helper()
+	q, err := NewFileJobQueue(dir, 0, historyLimit, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("NewFileJobQueue() = %v", err)
+	}
+	t.Cleanup(func() { q.Close() })
+	return q
+}
+
+func enqueue(t *testing.T, q queue.JobQueue, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		if err := q.Enqueue(context.Background(), &entity.Job{ID: id, Event: "push", DedupKey: "commit-" + id}); err != nil {
+			t.Fatalf("Enqueue(%s) = %v", id, err)
+		}
+	}
+}
+
+// dequeue returns the next job, failing the test instead of blocking when
+// there is none.
+func dequeue(t *testing.T, q queue.JobQueue) *entity.Job {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	job, err := q.Dequeue(ctx)
+	if err != nil {
+		t.Fatalf("Dequeue() = %v", err)
+	}
+	return job
+}
+
+func assertStatus(t *testing.T, q queue.JobQueue, id string, want entity.JobStatus) {
+	t.Helper()
+	job, err := q.Find(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Find(%s) = %v", id, err)
+	}
+	if job.Status != want {
+		t.Errorf("job %s is %s, want %s", id, job.Status, want)
+	}
+}
+
+func journalLines(t *testing.T, dir string) int {
+	t.Helper()
+	file, err := os.Open(filepath.Join(dir, journalFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	lines := 0
+	for scanner := bufio.NewScanner(file); scanner.Scan(); {
+		lines++
+	}
+	return lines
+}
+
+func TestFileJobQueueReplaysUnfinishedJobs(t *testing.T) {
+	dir := t.TempDir()
+	q := openQueue(t, dir, 10)
+	enqueue(t, q, "running", "queued")
+	if job := dequeue(t, q); job.ID != "running" {
+		t.Fatalf("Dequeue() = %s, want running", job.ID)
+	}
+	q.Close()
+
+	// Reopening is what happens after a crash or a restart
+	q = openQueue(t, dir, 10)
+	assertStatus(t, q, "running", entity.JobQueued)
+	assertStatus(t, q, "queued", entity.JobQueued)
+
+	first, second := dequeue(t, q), dequeue(t, q)
+	if first.ID != "running" || second.ID != "queued" {
+		t.Errorf("replayed %s then %s, want running then queued", first.ID, second.ID)
+	}
+	if first.Attempts != 2 {
+		t.Errorf("replayed job has %d attempts, want 2", first.Attempts)
+	}
+}
+
+func TestFileJobQueueSkipsTornLastLine(t *testing.T) {
+	dir := t.TempDir()
+	q := openQueue(t, dir, 10)
+	enqueue(t, q, "written")
+	q.Close()
+
+	// A crash in the middle of appending a record
+	journal, err := os.OpenFile(filepath.Join(dir, journalFile), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	journal.WriteString(`{"id":"torn","event":"pu`)
+	journal.Close()
+
+	q = openQueue(t, dir, 10)
+	assertStatus(t, q, "written", entity.JobQueued)
+	if _, err := q.Find(context.Background(), "torn"); !errors.Is(err, queue.ErrJobNotFound) {
+		t.Errorf("Find(torn) = %v, want ErrJobNotFound", err)
+	}
+
+	// Records appended after the torn line are not glued onto it
+	enqueue(t, q, "after")
+	q.Close()
+	q = openQueue(t, dir, 10)
+	assertStatus(t, q, "after", entity.JobQueued)
+}
+
+func TestFileJobQueueCompactionKeepsPendingAndFailedJobs(t *testing.T) {
+	dir := t.TempDir()
+	q := openQueue(t, dir, 1)
+	enqueue(t, q, "old", "failed", "newest", "pending")
+	ctx := context.Background()
+
+	for _, settle := range []func(id string) error{
+		func(id string) error { return q.Ack(ctx, id, entity.JobSucceeded, nil) },
+		func(id string) error { return q.DeadLetter(ctx, id, "bad payload", nil) },
+		func(id string) error { return q.Ack(ctx, id, entity.JobSkipped, nil) },
+	} {
+		if err := settle(dequeue(t, q).ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	q.Close()
+
+	// Opening compacts the journal down to the retained jobs
+	q = openQueue(t, dir, 1)
+	if lines := journalLines(t, dir); lines != 3 {
+		t.Errorf("compacted journal has %d records, want 3", lines)
+	}
+	if _, err := q.Find(ctx, "old"); !errors.Is(err, queue.ErrJobNotFound) {
+		t.Errorf("Find(old) = %v, want it pruned beyond the history limit", err)
+	}
+	assertStatus(t, q, "failed", entity.JobFailed)
+	assertStatus(t, q, "newest", entity.JobSkipped)
+	assertStatus(t, q, "pending", entity.JobQueued)
+	if job := dequeue(t, q); job.ID != "pending" {
+		t.Errorf("Dequeue() = %s, want pending", job.ID)
+	}
+}
+
+func TestFileJobQueueCloseKeepsUnackedJob(t *testing.T) {
+	dir := t.TempDir()
+	q := openQueue(t, dir, 10)
+	enqueue(t, q, "interrupted")
+	job := dequeue(t, q)
+
+	if err := q.Close(); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+	if err := q.Ack(context.Background(), job.ID, entity.JobSucceeded, nil); !errors.Is(err, queue.ErrQueueClosed) {
+		t.Errorf("Ack() after Close() = %v, want ErrQueueClosed", err)
+	}
+
+	q = openQueue(t, dir, 10)
+	if job := dequeue(t, q); job.ID != "interrupted" {
+		t.Errorf("Dequeue() = %s, want interrupted", job.ID)
+	}
+}
+
+func TestFileJobQueueRetriesAndDeadLetters(t *testing.T) {
+	dir := t.TempDir()
+	q := openQueue(t, dir, 10)
+	ctx := context.Background()
+	enqueue(t, q, "flaky")
+
+	// A retried job waits for its next attempt
+	job := dequeue(t, q)
+	due := time.Now().Add(100 * time.Millisecond)
+	if err := q.Retry(ctx, job.ID, due, "connection reset", nil); err != nil {
+		t.Fatalf("Retry() = %v", err)
+	}
+	retried, err := q.Find(ctx, job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retried.Status != entity.JobQueued || retried.LastError != "connection reset" || !retried.NextAttemptAt.Equal(due.UTC()) {
+		t.Errorf("retried job = %s, %q, due %s, want queued, connection reset, due %s", retried.Status, retried.LastError, retried.NextAttemptAt, due.UTC())
+	}
+
+	job = dequeue(t, q)
+	if time.Now().Before(due) {
+		t.Errorf("retried job was dequeued before it was due")
+	}
+	if job.Attempts != 2 {
+		t.Errorf("retried job has %d attempts, want 2", job.Attempts)
+	}
+
+	// A dead letter is kept as failed, across restarts, until redriven
+	if err := q.DeadLetter(ctx, job.ID, "still failing", nil); err != nil {
+		t.Fatalf("DeadLetter() = %v", err)
+	}
+	if err := q.Ack(ctx, job.ID, entity.JobSucceeded, nil); !errors.Is(err, queue.ErrJobNotFound) {
+		t.Errorf("Ack() of a dead letter = %v, want ErrJobNotFound", err)
+	}
+	q.Close()
+
+	q = openQueue(t, dir, 10)
+	assertStatus(t, q, job.ID, entity.JobFailed)
+	failed, err := q.List(ctx, entity.JobFailed, 0)
+	if err != nil || len(failed) != 1 || failed[0].LastError != "still failing" {
+		t.Fatalf("List(failed) = %v, %v, want the dead letter", failed, err)
+	}
+
+	if err := q.Redrive(ctx, job.ID); err != nil {
+		t.Fatalf("Redrive() = %v", err)
+	}
+	if err := q.Redrive(ctx, job.ID); !errors.Is(err, queue.ErrJobNotFound) {
+		t.Errorf("Redrive() of a queued job = %v, want ErrJobNotFound", err)
+	}
+	if job := dequeue(t, q); job.Attempts != 1 {
+		t.Errorf("redriven job has %d attempts, want a fresh start", job.Attempts)
+	}
+}
+
+func TestFileJobQueueRecognisesRedeliveriesAfterPruning(t *testing.T) {
+	dir := t.TempDir()
+	q := openQueue(t, dir, 1)
+	ctx := context.Background()
+	enqueue(t, q, "old", "new")
+	for range 2 {
+		if err := q.Ack(ctx, dequeue(t, q).ID, entity.JobSucceeded, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := q.Find(ctx, "old"); !errors.Is(err, queue.ErrJobNotFound) {
+		t.Fatalf("Find(old) = %v, want it pruned beyond the history limit", err)
+	}
+
+	// Also after reopening, once compaction has dropped the pruned job from the journal
+	for _, reopen := range []bool{false, true, true} {
+		if reopen {
+			q.Close()
+			q = openQueue(t, dir, 1)
+		}
+
+		redelivery := &entity.Job{ID: "redelivered", Event: "push", DedupKey: "commit-old"}
+		if err := q.Enqueue(ctx, redelivery); !errors.Is(err, queue.ErrJobExists) {
+			t.Errorf("Enqueue() of a pruned push = %v, want ErrJobExists (reopened: %t)", err, reopen)
+		}
+		existing, err := q.FindByDedupKey(ctx, "commit-old")
+		if err != nil || existing.ID != "old" || existing.Status != entity.JobSucceeded {
+			t.Errorf("FindByDedupKey() = %v, %v, want the pruned succeeded job (reopened: %t)", existing, err, reopen)
+		}
+	}
+}
@@ -2,19 +2,25 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/Suhaibshah22/pipeweaver/internal/domain/entity"
 	"github.com/Suhaibshah22/pipeweaver/internal/domain/port/repository"
+	"github.com/Suhaibshah22/pipeweaver/util"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/utils/merkletrie"
 )
 
 type gitRepositoryImpl struct {
@@ -24,6 +30,7 @@ type gitRepositoryImpl struct {
 
 	RepoPath  string
 	RemoteURL string
+	Branch    string
 }
 
 func NewGitRepository(remoteURL, branch, repoPath, username, token string) (repository.GitRepository, error) {
@@ -88,6 +95,7 @@ func NewGitRepository(remoteURL, branch, repoPath, username, token string) (repo
 		Auth:      auth,
 		RepoPath:  repoPath,
 		RemoteURL: remoteURL,
+		Branch:    branch,
 	}, nil
 
 }
@@ -106,6 +114,157 @@ func (g *gitRepositoryImpl) FindByPath(ctx context.Context, path string) (*entit
 	}, nil
 }
 
+// FindByPathAtRevision implements repository.GitRepository.
+func (g *gitRepositoryImpl) FindByPathAtRevision(ctx context.Context, path string, revision string) (*entity.File, error) {
+	hash, err := g.Repo.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve revision %s: %w", revision, err)
+	}
+
+	commit, err := g.Repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", hash, err)
+	}
+
+	file, err := commit.File(path)
+	if err == object.ErrFileNotFound {
+		return nil, fmt.Errorf("%s at %s: %w", path, revision, repository.ErrFileNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s at %s: %w", path, revision, err)
+	}
+
+	content, err := file.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s at %s: %w", path, revision, err)
+	}
+
+	return &entity.File{
+		Path:    path,
+		Content: []byte(content),
+	}, nil
+}
+
+// ListFilesAtRevision implements repository.GitRepository.
+func (g *gitRepositoryImpl) ListFilesAtRevision(ctx context.Context, dir string, revision string) ([]string, error) {
+	tree, err := g.treeAt(revision)
+	if err != nil || tree == nil {
+		return nil, err
+	}
+
+	prefix := strings.TrimSuffix(dir, "/") + "/"
+	var paths []string
+	err = tree.Files().ForEach(func(file *object.File) error {
+		if strings.HasPrefix(file.Name, prefix) {
+			paths = append(paths, file.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s at %s: %w", dir, revision, err)
+	}
+	return paths, nil
+}
+
+// Fetch implements repository.GitRepository.
+func (g *gitRepositoryImpl) Fetch(ctx context.Context, refSpecs ...string) error {
+	specs := make([]config.RefSpec, 0, len(refSpecs))
+	for _, spec := range refSpecs {
+		refSpec := config.RefSpec(spec)
+		if err := refSpec.Validate(); err != nil {
+			return fmt.Errorf("invalid refspec %s: %w", spec, err)
+		}
+		specs = append(specs, refSpec)
+	}
+
+	err := g.Repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: git.DefaultRemoteName,
+		RefSpecs:   specs,
+		Auth:       g.Auth,
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return classifyRemoteError(fmt.Errorf("failed to fetch %v: %w", refSpecs, err))
+	}
+
+	return nil
+}
+
+// Pull implements repository.GitRepository.
+func (g *gitRepositoryImpl) Pull(ctx context.Context) error {
+	err := g.Worktree.PullContext(ctx, &git.PullOptions{
+		ReferenceName: plumbing.NewBranchReferenceName(g.Branch),
+		SingleBranch:  true,
+		Auth:          g.Auth,
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return classifyRemoteError(fmt.Errorf("failed to pull repository: %w", err))
+	}
+	return nil
+}
+
+// ChangedFiles implements repository.GitRepository.
+func (g *gitRepositoryImpl) ChangedFiles(ctx context.Context, from string, to string) ([]entity.FileChange, error) {
+	fromTree, err := g.treeAt(from)
+	if err != nil {
+		return nil, err
+	}
+	toTree, err := g.treeAt(to)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := object.DiffTreeWithOptions(ctx, fromTree, toTree, object.DefaultDiffTreeOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff %s..%s: %w", from, to, err)
+	}
+
+	fileChanges := make([]entity.FileChange, 0, len(changes))
+	for _, change := range changes {
+		action, err := change.Action()
+		if err != nil {
+			return nil, fmt.Errorf("failed to classify change: %w", err)
+		}
+
+		switch action {
+		case merkletrie.Insert:
+			fileChanges = append(fileChanges, entity.FileChange{Action: entity.FileAdded, Path: change.To.Name})
+		case merkletrie.Delete:
+			fileChanges = append(fileChanges, entity.FileChange{Action: entity.FileRemoved, Path: change.From.Name})
+		case merkletrie.Modify:
+			if change.From.Name != change.To.Name {
+				fileChanges = append(fileChanges, entity.FileChange{Action: entity.FileRenamed, Path: change.To.Name, PreviousPath: change.From.Name})
+			} else {
+				fileChanges = append(fileChanges, entity.FileChange{Action: entity.FileModified, Path: change.To.Name})
+			}
+		}
+	}
+
+	return fileChanges, nil
+}
+
+// treeAt returns the tree of a revision, or nil (the empty tree) for an empty or zero revision.
+func (g *gitRepositoryImpl) treeAt(revision string) (*object.Tree, error) {
+	if strings.Trim(revision, "0") == "" {
+		return nil, nil
+	}
+
+	hash, err := g.Repo.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve revision %s: %w", revision, err)
+	}
+
+	commit, err := g.Repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", hash, err)
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tree of %s: %w", hash, err)
+	}
+	return tree, nil
+}
+
 // CreateBranch implements repository.GitRepository.
 func (g *gitRepositoryImpl) CreateBranch(ctx context.Context, branchName string) error {
 	headRef, err := g.Repo.Head()
@@ -141,16 +300,25 @@ func (g *gitRepositoryImpl) CommitAndPush(ctx context.Context, message string) e
 			When:  time.Now(),
 		},
 	})
+	if errors.Is(err, git.ErrEmptyCommit) {
+		return repository.ErrNothingToCommit
+	}
 	if err != nil {
 		return fmt.Errorf("failed to commit changes: %w", err)
 	}
 
-	//Push the changes
+	// Force-push the current branch only, it is owned by us and rebuilt on every run
+	head, err := g.Repo.Head()
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD reference: %w", err)
+	}
+
 	err = g.Repo.Push(&git.PushOptions{
-		Auth: g.Auth,
+		RefSpecs: []config.RefSpec{config.RefSpec(fmt.Sprintf("+%s:%s", head.Name(), head.Name()))},
+		Auth:     g.Auth,
 	})
 	if err != nil && err != git.NoErrAlreadyUpToDate {
-		return fmt.Errorf("failed to push changes: %w", err)
+		return classifyRemoteError(fmt.Errorf("failed to push changes: %w", err))
 	}
 
 	return nil
@@ -189,31 +357,63 @@ func (g *gitRepositoryImpl) Create(ctx context.Context, file *entity.File) error
 	return g.Update(ctx, file) // Similar to Update
 }
 
-// SwitchBackToMain implements repository.GitRepository.
-func (g *gitRepositoryImpl) SwitchBackToMain(ctx context.Context) error {
-	mainBranch := "main"
+// Delete implements repository.GitRepository.
+func (g *gitRepositoryImpl) Delete(ctx context.Context, path string) error {
+	fullPath := filepath.Join(g.RepoPath, path)
+	if _, err := os.Stat(fullPath); os.IsNotExist(err) {
+		return fmt.Errorf("%s: %w", path, repository.ErrFileNotFound)
+	}
+
+	log.Printf("Removing file at path: %s", fullPath)
 
-	// log.Print("Switching back to main branch", "branchName", mainBranch)
+	_, err := g.Worktree.Remove(path)
+	if err != nil {
+		return fmt.Errorf("failed to remove %s from worktree: %w", path, err)
+	}
+
+	log.Printf("Successfully removed file: %s", path)
+	return nil
+}
 
-	// Checkout the main branch
+// SwitchBackToMain implements repository.GitRepository. It checks out the
+// branch the repository was cloned with, the configured default branch.
+func (g *gitRepositoryImpl) SwitchBackToMain(ctx context.Context) error {
+	// log.Print("Switching back to default branch", "branchName", g.Branch)
+
+	// Checkout the default branch
 	err := g.Worktree.Checkout(&git.CheckoutOptions{
-		Branch: plumbing.NewBranchReferenceName(mainBranch),
+		Branch: plumbing.NewBranchReferenceName(g.Branch),
 	})
 	if err != nil {
-		// g.Logger.Error("Failed to switch back to main branch", "branchName", mainBranch, "error", err)
-		return fmt.Errorf("failed to switch back to main branch: %w", err)
+		// g.Logger.Error("Failed to switch back to default branch", "branchName", g.Branch, "error", err)
+		return fmt.Errorf("failed to switch back to %s branch: %w", g.Branch, err)
 	}
 
-	// g.Logger.Info("Switched back to main branch successfully", "branchName", mainBranch)
+	// g.Logger.Info("Switched back to default branch successfully", "branchName", g.Branch)
 	return nil
 }
 
 // DeleteBranch implements repository.GitRepository.
 func (g *gitRepositoryImpl) DeleteBranch(ctx context.Context, branchName string) error {
-
-	err := g.Repo.DeleteBranch(branchName)
+	err := g.Repo.Storer.RemoveReference(plumbing.NewBranchReferenceName(branchName))
 	if err != nil {
 		return fmt.Errorf("failed to delete branch: %w", err)
 	}
 	return nil
 }
+
+// classifyRemoteError marks failures talking to the remote as retryable, unless
+// trying again cannot help (bad credentials, missing repository, rejected updates).
+func classifyRemoteError(err error) error {
+	switch {
+	case errors.Is(err, transport.ErrAuthenticationRequired),
+		errors.Is(err, transport.ErrAuthorizationFailed),
+		errors.Is(err, transport.ErrRepositoryNotFound),
+		errors.Is(err, transport.ErrInvalidAuthMethod),
+		errors.Is(err, git.ErrNonFastForwardUpdate),
+		errors.Is(err, git.ErrForceNeeded):
+		return err
+	default:
+		return util.Retryable(err)
+	}
+}
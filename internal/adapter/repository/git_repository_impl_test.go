@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Suhaibshah22/pipeweaver/util"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+func TestClassifyRemoteError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{"network error", errors.New("dial tcp: i/o timeout"), true},
+		{"remote hung up", fmt.Errorf("fetch: %w", errors.New("unexpected EOF")), true},
+		{"authentication required", transport.ErrAuthenticationRequired, false},
+		{"authorization failed", fmt.Errorf("push: %w", transport.ErrAuthorizationFailed), false},
+		{"repository not found", transport.ErrRepositoryNotFound, false},
+		{"invalid auth method", transport.ErrInvalidAuthMethod, false},
+		{"non fast-forward", git.ErrNonFastForwardUpdate, false},
+		{"force needed", git.ErrForceNeeded, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classifyRemoteError(tt.err)
+			if got := util.IsRetryable(err); got != tt.retryable {
+				t.Errorf("IsRetryable(classifyRemoteError(%v)) = %t, want %t", tt.err, got, tt.retryable)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("classifyRemoteError(%v) = %v, want it to wrap the error", tt.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package validator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/port/validator"
+)
+
+// FilePlaceholder is replaced by the path of the DAG in the command arguments.
+const FilePlaceholder = "{file}"
+
+type commandValidator struct {
+	Command []string
+	Timeout time.Duration
+	Log     *slog.Logger
+}
+
+// NewCommandValidator checks DAGs by running an external command, e.g.
+// "python3 -m py_compile {file}" or a script importing the DAG into an Airflow
+// DagBag. The DAG is written to a temporary file whose path replaces {file},
+// or is appended when there is no placeholder. A non-zero exit rejects the DAG
+// with the command's output as the reason.
+//
+// It returns an error wrapping exec.ErrNotFound when the command is not
+// installed, so callers can carry on without it.
+func NewCommandValidator(command string, timeout time.Duration, logger *slog.Logger) (validator.DAGValidator, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, errors.New("empty DAG validation command")
+	}
+	if _, err := exec.LookPath(args[0]); err != nil {
+		return nil, fmt.Errorf("DAG validation command unavailable: %w", err)
+	}
+
+	return &commandValidator{
+		Command: args,
+		Timeout: timeout,
+		Log:     logger,
+	}, nil
+}
+
+func (v *commandValidator) Validate(ctx context.Context, dagPath string, content []byte) error {
+	// 1. Write the DAG where the command can read it, keeping its file name
+	// as Airflow imports modules by name
+	dir, err := os.MkdirTemp("", "pipeweaver-dag-")
+	if err != nil {
+		return fmt.Errorf("failed to create DAG validation directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, filepath.Base(dagPath))
+	if err := os.WriteFile(file, content, 0644); err != nil {
+		return fmt.Errorf("failed to write DAG for validation: %w", err)
+	}
+
+	// 2. Run the command
+	args := make([]string, 0, len(v.Command)+1)
+	placeholder := false
+	for _, arg := range v.Command[1:] {
+		if strings.Contains(arg, FilePlaceholder) {
+			placeholder = true
+			arg = strings.ReplaceAll(arg, FilePlaceholder, file)
+		}
+		args = append(args, arg)
+	}
+	if !placeholder {
+		args = append(args, file)
+	}
+
+	if v.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, v.Timeout)
+		defer cancel()
+	}
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, v.Command[0], args...)
+	cmd.Dir = dir
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	v.Log.Debug("Validating DAG", "dagPath", dagPath, "command", cmd.String())
+	err = cmd.Run()
+
+	// 3. Only a failing command rejects the DAG, anything else is our problem
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return nil
+	case ctx.Err() != nil:
+		return fmt.Errorf("DAG validation command timed out after %s: %w", v.Timeout, ctx.Err())
+	case errors.As(err, &exitErr):
+		reason := strings.TrimSpace(strings.ReplaceAll(output.String(), file, dagPath))
+		if reason == "" {
+			reason = exitErr.Error()
+		}
+		return fmt.Errorf("%w: %s was rejected by %s: %s", validator.ErrInvalidDAG, dagPath, v.Command[0], reason)
+	default:
+		return fmt.Errorf("failed to run DAG validation command: %w", err)
+	}
+}
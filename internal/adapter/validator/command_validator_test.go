@@ -0,0 +1,87 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/port/validator"
+)
+
+// shellValidator validates DAGs with a shell script, run as sh <script> args.
+func shellValidator(t *testing.T, script, args string, timeout time.Duration) validator.DAGValidator {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not installed")
+	}
+
+	path := filepath.Join(t.TempDir(), "check.sh")
+	if err := os.WriteFile(path, []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+	v, err := NewCommandValidator(strings.TrimSpace("sh "+path+" "+args), timeout, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestCommandValidatorAcceptsZeroExit(t *testing.T) {
+	v := shellValidator(t, `grep -q "dag = DAG" "$1"`, "", 0)
+
+	if err := v.Validate(context.Background(), "airflow-dags/orders.py", []byte("dag = DAG()\n")); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+}
+
+func TestCommandValidatorRejectsNonZeroExit(t *testing.T) {
+	v := shellValidator(t, `echo "SyntaxError in $1" >&2; exit 1`, "", 0)
+
+	err := v.Validate(context.Background(), "airflow-dags/orders.py", []byte("dag = (\n"))
+	if !errors.Is(err, validator.ErrInvalidDAG) {
+		t.Fatalf("Validate() = %v, want ErrInvalidDAG", err)
+	}
+	// The temporary file is reported as the DAG's own path
+	if !strings.Contains(err.Error(), "SyntaxError in airflow-dags/orders.py") {
+		t.Errorf("Validate() = %v, want the command output with the DAG path", err)
+	}
+}
+
+func TestCommandValidatorSubstitutesFile(t *testing.T) {
+	// The DAG is the second argument with a placeholder, and keeps its file name
+	v := shellValidator(t, `[ "$1" = "--dag" ] && [ "$(basename "$2")" = "orders.py" ] && grep -q "dag = DAG" "$2"`, "--dag "+FilePlaceholder, 0)
+
+	if err := v.Validate(context.Background(), "airflow-dags/orders.py", []byte("dag = DAG()\n")); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+}
+
+func TestCommandValidatorTimesOut(t *testing.T) {
+	v := shellValidator(t, `exec sleep 10`, "", 50*time.Millisecond)
+
+	start := time.Now()
+	err := v.Validate(context.Background(), "airflow-dags/orders.py", []byte("dag = DAG()\n"))
+	if err == nil || errors.Is(err, validator.ErrInvalidDAG) {
+		t.Fatalf("Validate() = %v, want a timeout that does not reject the DAG", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Validate() = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Validate() took %s, want the command killed after the timeout", elapsed)
+	}
+}
+
+func TestNewCommandValidatorMissingBinary(t *testing.T) {
+	_, err := NewCommandValidator("pipeweaver-no-such-command {file}", time.Second, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if !errors.Is(err, exec.ErrNotFound) {
+		t.Errorf("NewCommandValidator() = %v, want exec.ErrNotFound", err)
+	}
+}
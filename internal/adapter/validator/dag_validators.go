@@ -0,0 +1,25 @@
+package validator
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/port/validator"
+)
+
+// NewDAGValidators returns the checks every generated DAG must pass: the
+// built-in Python syntax check, followed by the validation command when one is
+// configured and installed.
+func NewDAGValidators(command string, timeout time.Duration, logger *slog.Logger) []validator.DAGValidator {
+	dagValidators := []validator.DAGValidator{NewPythonSyntaxValidator()}
+	if command == "" {
+		return dagValidators
+	}
+
+	commandValidator, err := NewCommandValidator(command, timeout, logger)
+	if err != nil {
+		logger.Warn("Skipping DAG validation command", "command", command, "error", err)
+		return dagValidators
+	}
+	return append(dagValidators, commandValidator)
+}
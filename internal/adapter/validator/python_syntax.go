@@ -0,0 +1,538 @@
+package validator
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SyntaxError is a problem found in Python source, positioned like Python
+// reports it: lines and columns start at 1.
+type SyntaxError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+type tokenKind int
+
+const (
+	tokenName tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenOperator
+	tokenNewline
+	tokenIndent
+	tokenDedent
+	tokenEOF
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	line   int
+	column int
+}
+
+var pythonKeywords = []string{
+	"False", "None", "True", "and", "as", "assert", "async", "await", "break",
+	"class", "continue", "def", "del", "elif", "else", "except", "finally",
+	"for", "from", "global", "if", "import", "in", "is", "lambda", "nonlocal",
+	"not", "or", "pass", "raise", "return", "try", "while", "with", "yield",
+}
+
+// softKeywords are only keywords at the start of some statements, e.g.
+// match x:, and can otherwise be used as names.
+var softKeywords = []string{"match", "case", "type"}
+
+// compoundKeywords start statements that must have a ':' followed by a block.
+var compoundKeywords = []string{"if", "elif", "else", "for", "while", "def", "class", "try", "except", "finally", "with"}
+
+var stringPrefixes = []string{"r", "u", "f", "b", "br", "rb", "fr", "rf"}
+
+// Longest first, so ** is not read as two *
+var operators = []string{
+	"**=", "//=", ">>=", "<<=", "...",
+	"->", ":=", "**", "//", "<<", ">>", "<=", ">=", "==", "!=",
+	"+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "@=",
+	"+", "-", "*", "/", "%", "@", "&", "|", "^", "~", "<", ">",
+	"(", ")", "[", "]", "{", "}", ",", ":", ".", ";", "=",
+}
+
+// binaryOperators need an operand on both sides.
+var binaryOperators = []string{
+	"**=", "//=", ">>=", "<<=", ":=", "//", "<<", ">>", "<=", ">=", "==", "!=",
+	"+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "@=",
+	"/", "%", "&", "|", "^", "<", ">", "=", ".",
+}
+
+var numberPattern = regexp.MustCompile(`^(?i:` +
+	`0[xX](_?[0-9a-f])+|0[oO](_?[0-7])+|0[bB](_?[01])+|` +
+	`(([1-9](_?[0-9])*|0(_?0)*)|(([0-9](_?[0-9])*)?\.[0-9](_?[0-9])*|[0-9](_?[0-9])*\.)([eE][+-]?[0-9](_?[0-9])*)?|[0-9](_?[0-9])*[eE][+-]?[0-9](_?[0-9])*)j?|` +
+	`[0-9](_?[0-9])*j)$`)
+
+// checkPythonSyntax tokenizes Python source the way the interpreter does and
+// checks the structure of every statement: balanced brackets, terminated
+// strings, consistent indentation, blocks after ':' and operands separated by
+// operators. It does not implement the whole grammar, but catches what a
+// template can get wrong.
+func checkPythonSyntax(source []byte) error {
+	if !utf8.Valid(source) {
+		return &SyntaxError{Line: 1, Column: 1, Message: "source is not valid UTF-8"}
+	}
+
+	tokens, err := tokenize(string(source))
+	if err != nil {
+		return err
+	}
+	return checkStatements(tokens)
+}
+
+type tokenizer struct {
+	source  string
+	offset  int
+	line    int
+	column  int
+	tokens  []token
+	indents []int
+	// open brackets, innermost last
+	brackets []token
+}
+
+func tokenize(source string) ([]token, error) {
+	t := &tokenizer{source: source, line: 1, column: 1, indents: []int{0}}
+
+	lineStart := true
+	for t.offset < len(t.source) {
+		if lineStart && len(t.brackets) == 0 {
+			lineStart = false
+			if err := t.indentation(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(t.source[t.offset:])
+		switch {
+		case r == '\n':
+			if len(t.brackets) == 0 {
+				t.emit(tokenNewline, "\n")
+				lineStart = true
+			}
+			t.advance(size)
+		case r == ' ' || r == '\t' || r == '\r' || r == '\f':
+			t.advance(size)
+		case r == '#':
+			for t.offset < len(t.source) && t.source[t.offset] != '\n' {
+				t.advance(1)
+			}
+		case r == '\\':
+			next := t.offset + 1
+			if next < len(t.source) && t.source[next] == '\r' {
+				next++
+			}
+			if next >= len(t.source) || t.source[next] != '\n' {
+				return nil, t.errorf("unexpected character after line continuation character")
+			}
+			t.advance(next - t.offset + 1)
+		case r == '"' || r == '\'':
+			if err := t.string(t.offset); err != nil {
+				return nil, err
+			}
+		case r == '_' || unicode.IsLetter(r):
+			start, line, column := t.offset, t.line, t.column
+			for t.offset < len(t.source) {
+				r, size := utf8.DecodeRuneInString(t.source[t.offset:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.In(r, unicode.Mn, unicode.Mc, unicode.Pc) {
+					break
+				}
+				t.advance(size)
+			}
+			name := t.source[start:t.offset]
+			if t.offset < len(t.source) && (t.source[t.offset] == '"' || t.source[t.offset] == '\'') &&
+				slices.Contains(stringPrefixes, strings.ToLower(name)) {
+				t.offset, t.line, t.column = start, line, column
+				if err := t.string(start); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			t.tokens = append(t.tokens, token{kind: tokenName, text: name, line: line, column: column})
+		case unicode.IsDigit(r) || (r == '.' && t.offset+1 < len(t.source) && isDigit(t.source[t.offset+1])):
+			if err := t.number(); err != nil {
+				return nil, err
+			}
+		default:
+			if err := t.operator(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(t.brackets) > 0 {
+		open := t.brackets[len(t.brackets)-1]
+		return nil, &SyntaxError{Line: open.line, Column: open.column, Message: fmt.Sprintf("'%s' was never closed", open.text)}
+	}
+	if n := len(t.tokens); n > 0 && t.tokens[n-1].kind != tokenNewline {
+		t.emit(tokenNewline, "")
+	}
+	for len(t.indents) > 1 {
+		t.indents = t.indents[:len(t.indents)-1]
+		t.emit(tokenDedent, "")
+	}
+	t.emit(tokenEOF, "")
+	return t.tokens, nil
+}
+
+func (t *tokenizer) advance(size int) {
+	for _, b := range []byte(t.source[t.offset : t.offset+size]) {
+		if b == '\n' {
+			t.line++
+			t.column = 1
+		} else if b&0xC0 != 0x80 {
+			t.column++
+		}
+	}
+	t.offset += size
+}
+
+func (t *tokenizer) emit(kind tokenKind, text string) {
+	t.tokens = append(t.tokens, token{kind: kind, text: text, line: t.line, column: t.column})
+}
+
+func (t *tokenizer) errorf(format string, args ...any) error {
+	return &SyntaxError{Line: t.line, Column: t.column, Message: fmt.Sprintf(format, args...)}
+}
+
+// indentation measures the indentation of a line and emits INDENT or DEDENT
+// tokens when it changes. Blank and comment only lines are skipped.
+func (t *tokenizer) indentation() error {
+	for t.offset < len(t.source) {
+		width := 0
+	measure:
+		for t.offset < len(t.source) {
+			switch t.source[t.offset] {
+			case ' ':
+				width++
+			case '\t':
+				width += 8 - width%8
+			case '\f':
+				width = 0
+			default:
+				break measure
+			}
+			t.advance(1)
+		}
+		if t.offset >= len(t.source) {
+			return nil
+		}
+
+		switch t.source[t.offset] {
+		case '\n', '\r', '#':
+			// Blank lines do not change the indentation
+			for t.offset < len(t.source) && t.source[t.offset] != '\n' {
+				t.advance(1)
+			}
+			if t.offset < len(t.source) {
+				t.advance(1)
+			}
+			continue
+		}
+
+		current := t.indents[len(t.indents)-1]
+		switch {
+		case width > current:
+			t.indents = append(t.indents, width)
+			t.emit(tokenIndent, "")
+		case width < current:
+			for width < t.indents[len(t.indents)-1] {
+				t.indents = t.indents[:len(t.indents)-1]
+				t.emit(tokenDedent, "")
+			}
+			if width != t.indents[len(t.indents)-1] {
+				return t.errorf("unindent does not match any outer indentation level")
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+func (t *tokenizer) string(start int) error {
+	line, column := t.line, t.column
+
+	// Prefix, e.g. rb
+	for t.source[t.offset] != '"' && t.source[t.offset] != '\'' {
+		t.advance(1)
+	}
+	quote := t.source[t.offset]
+	delimiter := string(quote)
+	if strings.HasPrefix(t.source[t.offset:], strings.Repeat(delimiter, 3)) {
+		delimiter = strings.Repeat(delimiter, 3)
+	}
+	t.advance(len(delimiter))
+
+	for {
+		if t.offset >= len(t.source) {
+			if len(delimiter) == 3 {
+				return &SyntaxError{Line: line, Column: column, Message: "unterminated triple-quoted string literal"}
+			}
+			return &SyntaxError{Line: line, Column: column, Message: "unterminated string literal"}
+		}
+		switch {
+		case t.source[t.offset] == '\\':
+			// The escaped character never ends the string, even a newline
+			t.advance(1)
+			if t.offset < len(t.source) {
+				_, size := utf8.DecodeRuneInString(t.source[t.offset:])
+				t.advance(size)
+			}
+		case t.source[t.offset] == '\n' && len(delimiter) == 1:
+			return &SyntaxError{Line: line, Column: column, Message: "unterminated string literal"}
+		case strings.HasPrefix(t.source[t.offset:], delimiter):
+			t.advance(len(delimiter))
+			t.tokens = append(t.tokens, token{kind: tokenString, text: t.source[start:t.offset], line: line, column: column})
+			return nil
+		default:
+			_, size := utf8.DecodeRuneInString(t.source[t.offset:])
+			t.advance(size)
+		}
+	}
+}
+
+func (t *tokenizer) number() error {
+	start, line, column := t.offset, t.line, t.column
+	for t.offset < len(t.source) {
+		b := t.source[t.offset]
+		exponentSign := (b == '+' || b == '-') && t.offset > start &&
+			(t.source[t.offset-1] == 'e' || t.source[t.offset-1] == 'E') &&
+			!strings.HasPrefix(strings.ToLower(t.source[start:]), "0x")
+		if !isDigit(b) && !isLetter(b) && b != '_' && b != '.' && !exponentSign {
+			break
+		}
+		// A second dot is an attribute, as in 1.5.real
+		if b == '.' && (strings.Contains(t.source[start:t.offset], ".") || hasExponent(t.source[start:t.offset])) {
+			break
+		}
+		t.advance(1)
+	}
+
+	number := t.source[start:t.offset]
+	if !numberPattern.MatchString(number) {
+		return &SyntaxError{Line: line, Column: column, Message: fmt.Sprintf("invalid number %q", number)}
+	}
+	t.tokens = append(t.tokens, token{kind: tokenNumber, text: number, line: line, column: column})
+	return nil
+}
+
+func (t *tokenizer) operator() error {
+	for _, operator := range operators {
+		if !strings.HasPrefix(t.source[t.offset:], operator) {
+			continue
+		}
+
+		tok := token{kind: tokenOperator, text: operator, line: t.line, column: t.column}
+		switch operator {
+		case "(", "[", "{":
+			t.brackets = append(t.brackets, tok)
+		case ")", "]", "}":
+			if len(t.brackets) == 0 {
+				return t.errorf("unmatched '%s'", operator)
+			}
+			open := t.brackets[len(t.brackets)-1]
+			if closing[open.text] != operator {
+				return t.errorf("closing parenthesis '%s' does not match opening parenthesis '%s' on line %d", operator, open.text, open.line)
+			}
+			t.brackets = t.brackets[:len(t.brackets)-1]
+		}
+		t.tokens = append(t.tokens, tok)
+		t.advance(len(operator))
+		return nil
+	}
+
+	r, _ := utf8.DecodeRuneInString(t.source[t.offset:])
+	return t.errorf("invalid character %q (U+%04X)", r, r)
+}
+
+var closing = map[string]string{"(": ")", "[": "]", "{": "}"}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// hasExponent reports whether a decimal number has an exponent or is imaginary.
+func hasExponent(number string) bool {
+	lower := strings.ToLower(number)
+	if strings.HasPrefix(lower, "0x") || strings.HasPrefix(lower, "0b") || strings.HasPrefix(lower, "0o") {
+		return false
+	}
+	return strings.ContainsAny(lower, "ej")
+}
+
+func isLetter(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// checkStatements checks the logical lines of a tokenized file.
+func checkStatements(tokens []token) error {
+	var previous []token // the previous logical line
+	var line []token
+
+	for _, tok := range tokens {
+		switch tok.kind {
+		case tokenIndent:
+			if len(previous) == 0 || previous[len(previous)-1].text != ":" {
+				return &SyntaxError{Line: tok.line, Column: tok.column, Message: "unexpected indent"}
+			}
+			previous = nil
+			continue
+		case tokenDedent:
+			continue
+		case tokenNewline, tokenEOF:
+			if len(line) == 0 {
+				continue
+			}
+			if len(previous) > 0 && previous[len(previous)-1].text == ":" {
+				return &SyntaxError{Line: line[0].line, Column: line[0].column, Message: fmt.Sprintf("expected an indented block after line %d", previous[0].line)}
+			}
+			if err := checkStatement(line); err != nil {
+				return err
+			}
+			previous, line = line, nil
+			continue
+		}
+
+		if len(line) == 0 && len(previous) > 0 && previous[len(previous)-1].text == ":" && tok.kind != tokenIndent {
+			return &SyntaxError{Line: tok.line, Column: tok.column, Message: fmt.Sprintf("expected an indented block after line %d", previous[0].line)}
+		}
+		line = append(line, tok)
+	}
+
+	if len(previous) > 0 && previous[len(previous)-1].text == ":" {
+		last := previous[len(previous)-1]
+		return &SyntaxError{Line: last.line, Column: last.column, Message: "expected an indented block at the end of the file"}
+	}
+	return nil
+}
+
+// checkStatement checks a single logical line.
+func checkStatement(line []token) error {
+	first := line[0]
+	if first.kind == tokenName && slices.Contains(compoundKeywords, first.text) {
+		if !hasTopLevelColon(line) {
+			last := line[len(line)-1]
+			return &SyntaxError{Line: last.line, Column: last.column + len(last.text), Message: "expected ':'"}
+		}
+		if (first.text == "def" || first.text == "class") && (len(line) < 2 || line[1].kind != tokenName || isKeyword(line[1].text)) {
+			return syntaxErrorAt(first, fmt.Sprintf("expected a name after %s", first.text))
+		}
+	}
+
+	for i, tok := range line {
+		var before *token
+		if i > 0 {
+			before = &line[i-1]
+		}
+
+		// Two operands in a row, e.g. print "x" or a b
+		if before != nil && isOperandEnd(*before) && isOperandStart(tok) &&
+			!(before.kind == tokenString && tok.kind == tokenString) {
+			return syntaxErrorAt(tok, "invalid syntax, perhaps you forgot a comma?")
+		}
+
+		if before != nil && before.text == "," && tok.text == "," {
+			return syntaxErrorAt(tok, "invalid syntax")
+		}
+
+		// An operator that needs an operand on its left
+		if tok.kind == tokenOperator && slices.Contains(binaryOperators, tok.text) &&
+			(before == nil || (before.kind == tokenOperator && !isClosing(before.text)) || (before.kind == tokenName && isKeyword(before.text) && !isConstant(before.text))) {
+			// Relative imports, from . import x or from .. import x
+			relativeImport := tok.text == "." && before != nil && (before.text == "from" || before.text == "." || before.text == "...")
+			// Positional-only parameters, def f(a, /, b)
+			positionalOnly := tok.text == "/" && before != nil && (before.text == "," || before.text == "(") &&
+				i+1 < len(line) && (line[i+1].text == "," || line[i+1].text == ")" || line[i+1].text == ":")
+			// Unpacking a single item, x, = items
+			unpacking := tok.text == "=" && before != nil && before.text == ","
+			if !relativeImport && !positionalOnly && !unpacking {
+				return syntaxErrorAt(tok, "invalid syntax")
+			}
+		}
+	}
+
+	// Lines can only end in an operator that needs no right operand, or in
+	// from x import *
+	last := line[len(line)-1]
+	if last.kind == tokenOperator && !isClosing(last.text) && !slices.Contains([]string{":", ",", ";", "..."}, last.text) &&
+		!(last.text == "*" && len(line) > 1 && line[len(line)-2].text == "import") {
+		return syntaxErrorAt(last, "invalid syntax, expected an expression after "+last.text)
+	}
+	return nil
+}
+
+func hasTopLevelColon(line []token) bool {
+	depth := 0
+	lambdas := 0
+	for _, tok := range line {
+		switch {
+		case tok.kind == tokenOperator && (tok.text == "(" || tok.text == "[" || tok.text == "{"):
+			depth++
+		case tok.kind == tokenOperator && isClosing(tok.text):
+			depth--
+		case depth == 0 && tok.kind == tokenName && tok.text == "lambda":
+			lambdas++
+		case depth == 0 && tok.kind == tokenOperator && tok.text == ":":
+			if lambdas == 0 {
+				return true
+			}
+			lambdas--
+		}
+	}
+	return false
+}
+
+func isOperandEnd(tok token) bool {
+	switch tok.kind {
+	case tokenNumber, tokenString:
+		return true
+	case tokenName:
+		// Soft keywords may start a statement, as in match x:
+		return (!isKeyword(tok.text) && !slices.Contains(softKeywords, tok.text)) || isConstant(tok.text)
+	case tokenOperator:
+		return isClosing(tok.text)
+	}
+	return false
+}
+
+func isOperandStart(tok token) bool {
+	switch tok.kind {
+	case tokenNumber, tokenString:
+		return true
+	case tokenName:
+		return !isKeyword(tok.text) || isConstant(tok.text)
+	}
+	return false
+}
+
+func isKeyword(name string) bool {
+	return slices.Contains(pythonKeywords, name)
+}
+
+func isConstant(name string) bool {
+	return name == "None" || name == "True" || name == "False"
+}
+
+func isClosing(operator string) bool {
+	return operator == ")" || operator == "]" || operator == "}"
+}
+
+func syntaxErrorAt(tok token, message string) error {
+	return &SyntaxError{Line: tok.line, Column: tok.column, Message: message}
+}
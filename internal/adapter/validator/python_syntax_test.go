@@ -0,0 +1,113 @@
+package validator
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckPythonSyntaxAcceptsValidPython(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"empty file", ""},
+		{"decorators", "@dag(schedule=None)\n@task.python\ndef load(x, /, y, *, z=1, **kwargs) -> None:\n    return x\n"},
+		{"class", "class Loader(Base, metaclass=Meta):\n    \"\"\"Loads.\"\"\"\n\n    def run(self):\n        pass\n"},
+		{"f-strings", "name = f\"{table!r:>10} {{literal}}\"\nrb = rb'\\x00'\nFr = Fr'{x}'\n"},
+		{"nested quotes", "x = \"it's\" 'say \"hi\"' \"\"\"one \" two \"\" three\"\"\"\n"},
+		{"implicit concatenation", "sql = (\"SELECT *\"\n       \" FROM t\")\n"},
+		{"line continuation", "total = 1 + \\\n    2\nif a and \\\n        b:\n    pass\n"},
+		{"brackets across lines", "x = [\n    1,\n  2,\n        3,\n]\nf(a,\n  b=2)\n"},
+		{"match", "match command:\n    case [\"go\", direction]:\n        pass\n    case _:\n        pass\nmatch = 1\n"},
+		{"lambda with dict", "f = lambda x: {\"key\": x}\nd = {k: lambda v: v for k in ks}\n"},
+		{"lambda in compound statement", "if (lambda: True)():\n    pass\nfor f in [lambda a, b=1: a]:\n    pass\n"},
+		{"relative imports", "from . import tasks\nfrom .. import x\nfrom ...pkg import y\nfrom .mod import *\n"},
+		{"numbers", "n = [0, 1_000, 0x_ff, 0o17, 0b1, 1.5, .5, 5., 1e10, 1.5E-3, 2j, 1_0.0_1e+1_0j]\n"},
+		{"walrus and unpacking", "if (n := len(x)) > 1:\n    a, = x\n    first, *rest = x\n"},
+		{"ellipsis", "def stub(): ...\nx[..., 0]\n"},
+		{"comments and blank lines", "# comment\n\nx = 1  # trailing\n    # indented comment\n\ny = 2\n"},
+		{"semicolons", "a = 1; b = 2;\n"},
+		{"one line compound", "if x: pass\nelse: y = 1\n"},
+		{"try", "try:\n    pass\nexcept (ValueError, TypeError) as e:\n    raise\nelse:\n    pass\nfinally:\n    pass\n"},
+		{"unicode names", "café = 1\nnaïve_ŝ = café\n"},
+		{"tabs", "if x:\n\tpass\n"},
+		{"no trailing newline", "x = 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkPythonSyntax([]byte(tt.source)); err != nil {
+				t.Errorf("checkPythonSyntax(%q) = %v, want nil", tt.source, err)
+			}
+		})
+	}
+}
+
+func TestCheckPythonSyntaxAcceptsRenderedTemplates(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.py"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no rendered DAGs in testdata: %v", err)
+	}
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			content, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := checkPythonSyntax(content); err != nil {
+				t.Errorf("checkPythonSyntax(%s) = %v, want nil", file, err)
+			}
+		})
+	}
+}
+
+func TestCheckPythonSyntaxRejectsInvalidPython(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		line    int
+		message string
+	}{
+		{"unterminated string", "x = \"abc\ny = 1\n", 1, "unterminated string"},
+		{"unterminated triple quoted string", "x = \"\"\"abc\n", 1, "unterminated"},
+		{"string ending in escaped quote", "x = 'abc\\'\n", 1, "unterminated string"},
+		{"unclosed bracket", "x = (1,\n", 1, "never closed"},
+		{"unmatched bracket", "x = 1)\n", 1, "unmatched"},
+		{"mismatched bracket", "x = [1, 2)\n", 1, "does not match"},
+		{"missing colon", "if x\n    pass\n", 1, "expected ':'"},
+		{"missing colon after def", "def f()\n    pass\n", 1, "expected ':'"},
+		{"colon only inside lambda", "if lambda: x\n    pass\n", 1, "expected ':'"},
+		{"missing block", "if x:\ny = 1\n", 2, "expected an indented block"},
+		{"missing block at end", "for x in y:\n", 1, "expected an indented block"},
+		{"unexpected indent", "x = 1\n    y = 2\n", 2, "unexpected indent"},
+		{"inconsistent dedent", "if x:\n    y = 1\n  z = 2\n", 3, "indent"},
+		{"dangling operator", "x = 1 +\n", 1, "expected an expression after +"},
+		{"dangling assignment", "x =\n", 1, "expected an expression after ="},
+		{"missing left operand", "y = / 2\n", 1, "invalid syntax"},
+		{"missing comma", "print \"x\"\n", 1, "forgot a comma"},
+		{"double comma", "f(a,, b)\n", 1, "invalid syntax"},
+		{"two dots in a number", "x = 1.2.3\n", 1, ""},
+		{"keyword as name", "def class(): pass\n", 1, "expected a name after def"},
+		{"invalid UTF-8", "x = \"\xff\"\n", 1, "UTF-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkPythonSyntax([]byte(tt.source))
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("checkPythonSyntax(%q) = %v, want a SyntaxError", tt.source, err)
+			}
+			if syntaxErr.Line != tt.line {
+				t.Errorf("checkPythonSyntax(%q) reported line %d, want %d: %v", tt.source, syntaxErr.Line, tt.line, err)
+			}
+			if !strings.Contains(syntaxErr.Message, tt.message) {
+				t.Errorf("checkPythonSyntax(%q) = %q, want it to mention %q", tt.source, syntaxErr.Message, tt.message)
+			}
+		})
+	}
+}
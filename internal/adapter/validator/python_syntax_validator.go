@@ -0,0 +1,23 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/port/validator"
+)
+
+type pythonSyntaxValidator struct{}
+
+// NewPythonSyntaxValidator checks that DAGs are syntactically valid Python,
+// without needing a Python interpreter.
+func NewPythonSyntaxValidator() validator.DAGValidator {
+	return &pythonSyntaxValidator{}
+}
+
+func (v *pythonSyntaxValidator) Validate(ctx context.Context, dagPath string, content []byte) error {
+	if err := checkPythonSyntax(content); err != nil {
+		return fmt.Errorf("%w: %s is not valid Python: %w", validator.ErrInvalidDAG, dagPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+"""
+Auto-generated Airflow DAG
+Do not modify this file directly,
+update the pipeline configuration file.
+
+Pipeline Name: pg_to_sf
+Description: Extract some data and load some data
+"""
+
+import os
+from datetime import datetime
+from airflow import DAG
+from airflow.operators.python_operator import PythonOperator
+
+default_args = {
+    "owner": "airflow",
+    "start_date": datetime(2023, 1, 1),
+    "retries": 1
+}
+
+dag = DAG(
+    dag_id="pg_to_sf",
+    default_args=default_args,
+    description="Extract some data and load some data",
+    schedule_interval="0 3 * * *",
+    catchup=False
+)
+
+def run_extract_and_load(**context):
+    """
+    Placeholder Python function for the extract-and-load step (ingestion).
+    Extract from Postgres, load into Snowflake
+    """
+    print("Reading from postgres postgres-source (host=subscription-db.exampled.com, database=subscriptions, table=user_subscriptions)")
+    print("Writing to snowflake snowflake-dest (table=analytics.user_subscriptions)")
+    print("Success: step extract-and-load completed!")
+
+extract_and_load_task = PythonOperator(
+    task_id="extract-and-load",
+    python_callable=run_extract_and_load,
+    dag=dag
+)
@@ -0,0 +1,117 @@
+"""
+Auto-generated Airflow DAG
+Do not modify this file directly,
+update the pipeline configuration file.
+
+Pipeline Name: all_types
+Description: Every step type
+"""
+
+import os
+from datetime import datetime
+
+from airflow import DAG
+from acme.tasks import load
+from airflow.operators.bash import BashOperator
+from airflow.operators.generic_transfer import GenericTransfer
+from airflow.operators.python import PythonOperator
+from airflow.providers.amazon.aws.transfers.sql_to_s3 import SqlToS3Operator
+from airflow.providers.cncf.kubernetes.operators.pod import KubernetesPodOperator
+from airflow.providers.common.sql.operators.sql import SQLExecuteQueryOperator
+from airflow.providers.postgres.operators.postgres import PostgresOperator
+from airflow.providers.slack.notifications.slack import send_slack_notification
+from airflow.providers.slack.notifications.slack_webhook import send_slack_webhook_notification
+from airflow.providers.smtp.notifications.smtp import send_smtp_notification
+from airflow.providers.snowflake.transfers.copy_into_snowflake import CopyFromExternalStageToSnowflakeOperator
+
+default_args = {
+    "owner": "Data Team,Jo \"J\" Doe",
+    "email": ["data@acme.io"],
+    "retries": 1
+}
+
+dag = DAG(
+    dag_id="all_types",
+    default_args=default_args,
+    description="Every step type",
+    schedule="@daily",
+    start_date=datetime(2023, 1, 1),
+    catchup=False,
+    tags=["domain:ops", "version:2.0.0"],
+    doc_md="# all_types\n\nEvery step type\n\n**Owners:** Data Team <data@acme.io>, Jo \"J\" Doe\n\n## Steps\n\n- **cleanup** (bash): Removes \"scratch\" files\n",
+    template_searchpath=[os.path.join(os.path.dirname(os.path.abspath(__file__)), "../sql/ops/all")],
+    on_success_callback=[send_slack_notification(channel="#data", text="DAG {{ dag.dag_id }} succeeded in run {{ run_id }}.", slack_conn_id="slack_api_default")],
+    on_failure_callback=[send_smtp_notification(to=["data@acme.io", "oncall@acme.io"], subject="DAG {{ dag.dag_id }} failed", html_content="DAG {{ dag.dag_id }} failed in run {{ run_id }}.", smtp_conn_id="smtp_default"), send_slack_webhook_notification(text="DAG {{ dag.dag_id }} failed in run {{ run_id }}.", slack_webhook_conn_id="alerts_hook")],
+)
+
+extract_and_load_task = GenericTransfer(
+    task_id="extract-and-load",
+    sql="SELECT * FROM public.users",
+    source_conn_id="postgres_default",
+    destination_table="analytics.users",
+    destination_conn_id="snowflake_prod",
+    dag=dag
+)
+
+to_s3_task = SqlToS3Operator(
+    task_id="to-s3",
+    query="to-s3.sql",
+    sql_conn_id="mysql_default",
+    s3_bucket="lake",
+    s3_key="orders/{{ ds }}.csv",
+    aws_conn_id="aws_default",
+    file_format="parquet",
+    dag=dag
+)
+
+stage_load_task = CopyFromExternalStageToSnowflakeOperator(
+    task_id="stage-load",
+    table="raw.orders",
+    snowflake_conn_id="snowflake_default",
+    file_format="(type = 'CSV')",
+    stage="lake_stage",
+    dag=dag
+)
+
+transform_task = SQLExecuteQueryOperator(
+    task_id="transform",
+    sql="transform.sql",
+    conn_id="snowflake_default",
+    database="ANALYTICS",
+    dag=dag
+)
+
+cleanup_task = BashOperator(
+    task_id="cleanup",
+    on_failure_callback=[send_slack_notification(channel="#data-alerts", text="Task {{ ti.task_id }} of DAG {{ dag.dag_id }} failed in run {{ run_id }}. Logs: {{ ti.log_url }}", slack_conn_id="slack_api"), send_smtp_notification(to=["ops@acme.io"], subject="Task {{ ti.task_id }} of {{ dag.dag_id }} failed", html_content="Task {{ ti.task_id }} of DAG {{ dag.dag_id }} failed in run {{ run_id }}. Logs: {{ ti.log_url }}", smtp_conn_id="smtp_ops")],
+    bash_command="echo done && rm -rf /tmp/x",
+    env={"A": "1"},
+    dag=dag
+)
+
+py_task = PythonOperator(
+    task_id="py",
+    python_callable=load,
+    op_kwargs={"dry": True, "limit": 10},
+    dag=dag
+)
+
+pod_run_task = KubernetesPodOperator(
+    task_id="pod_run",
+    name="pod-run",
+    cmds=["python", "-m", "job"],
+    image="acme/job:1.2",
+    namespace="data",
+    dag=dag
+)
+
+custom_task = PostgresOperator(
+    task_id="custom",
+    postgres_conn_id="pg",
+    sql="VACUUM",
+    dag=dag
+)
+
+# Step dependencies
+extract_and_load_task >> transform_task
+transform_task >> cleanup_task
@@ -4,3 +4,22 @@ type File struct {
 	Path    string
 	Content []byte
 }
+
+// ChangeAction describes what happened to a file between two commits.
+type ChangeAction string
+
+const (
+	FileAdded    ChangeAction = "added"
+	FileModified ChangeAction = "modified"
+	FileRemoved  ChangeAction = "removed"
+	FileRenamed  ChangeAction = "renamed"
+)
+
+// FileChange is a single file changed between two commits.
+type FileChange struct {
+	Action ChangeAction
+	Path   string
+
+	// PreviousPath is set for renamed files.
+	PreviousPath string
+}
@@ -0,0 +1,68 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// JobStatus tracks a job through the processing queue:
+// queued → running → succeeded, failed or skipped.
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobSkipped   JobStatus = "skipped"
+
+	// JobFailed jobs failed permanently or ran out of attempts. They are kept
+	// as dead letters until redriven.
+	JobFailed JobStatus = "failed"
+)
+
+// Finished reports whether a job has reached a final state.
+func (s JobStatus) Finished() bool {
+	return s == JobSucceeded || s == JobSkipped || s == JobFailed
+}
+
+// Job is a unit of work in the processing queue, typically one webhook delivery.
+type Job struct {
+	ID         string          `json:"id"`
+	DeliveryID string          `json:"delivery_id,omitempty"`
+	Event      string          `json:"event"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	Status     JobStatus       `json:"status"`
+	Result     *JobResult      `json:"result,omitempty"`
+
+	// DedupKey identifies the work a job does, e.g. the commit a push brought
+	// main to, so redelivered events are only processed once.
+	DedupKey string `json:"dedup_key,omitempty"`
+
+	// Attempts counts how often the job has been dequeued.
+	Attempts      int       `json:"attempts"`
+	NextAttemptAt time.Time `json:"next_attempt_at,omitempty"`
+	LastError     string    `json:"last_error,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// JobResult records what processing a job did.
+type JobResult struct {
+	Files          []FileResult `json:"files,omitempty"`
+	Branch         string       `json:"branch,omitempty"`
+	PullRequestURL string       `json:"pull_request_url,omitempty"`
+
+	// SkipReason is set when there was nothing to do.
+	SkipReason string `json:"skip_reason,omitempty"`
+}
+
+// FileResult is the outcome for one pipeline file.
+type FileResult struct {
+	PipelinePath string    `json:"pipeline_path"`
+	DAGPath      string    `json:"dag_path,omitempty"`
+	Action       string    `json:"action"`
+	Status       JobStatus `json:"status"`
+	Error        string    `json:"error,omitempty"`
+	Warnings     []string  `json:"warnings,omitempty"`
+}
@@ -16,6 +16,9 @@ type Pipeline struct {
 	Schedule    *Schedule              `yaml:"schedule,omitempty"`
 	Parameters  map[string]interface{} `yaml:"parameters,omitempty"` // could be more structured if you prefer
 	Steps       []Step                 `yaml:"steps"`
+
+	// Notifications are sent when a run of the whole pipeline finishes
+	Notifications *Notifications `yaml:"notifications,omitempty"`
 }
 
 type Owner struct {
@@ -23,22 +26,32 @@ type Owner struct {
 	Email string `yaml:"email"`
 }
 
+// Schedule is when the pipeline runs. Without a type the expression decides
+// it: @daily is a preset, 1d12h a timedelta and anything else cron.
 type Schedule struct {
-	Type       string `yaml:"type,omitempty"`
-	Expression string `yaml:"expression,omitempty"`
+	Type       string   `yaml:"type,omitempty"`
+	Expression string   `yaml:"expression,omitempty"`
+	Datasets   []string `yaml:"datasets,omitempty"` // URIs of the datasets triggering a dataset schedule
+
+	Timezone      string `yaml:"timezone,omitempty"`   // IANA name, e.g. Europe/Amsterdam
+	StartDate     string `yaml:"start_date,omitempty"` // 2024-01-01 or 2024-01-01T06:00, in Timezone
+	EndDate       string `yaml:"end_date,omitempty"`
+	Catchup       *bool  `yaml:"catchup,omitempty"`
+	MaxActiveRuns int    `yaml:"max_active_runs,omitempty"`
 }
 
 // Step represents a discrete stage in the pipeline (ingestion, transformation, etc.).
 type Step struct {
-	Name                string         `yaml:"name"`
-	Type                string         `yaml:"type"`
-	Description         string         `yaml:"description"`
-	DependsOn           []string       `yaml:"depends_on,omitempty"`
-	Inputs              []DataRef      `yaml:"inputs,omitempty"`
-	Outputs             []DataRef      `yaml:"outputs,omitempty"`
-	Config              interface{}    `yaml:"config,omitempty"` // or map[string]interface{}
-	TransformationQuery string         `yaml:"transformation_query,omitempty"`
-	Notifications       *Notifications `yaml:"notifications,omitempty"`
+	Name                    string         `yaml:"name"`
+	Type                    string         `yaml:"type"`
+	Description             string         `yaml:"description"`
+	DependsOn               []string       `yaml:"depends_on,omitempty"`
+	Inputs                  []DataRef      `yaml:"inputs,omitempty"`
+	Outputs                 []DataRef      `yaml:"outputs,omitempty"`
+	Config                  interface{}    `yaml:"config,omitempty"` // or map[string]interface{}
+	TransformationQuery     string         `yaml:"transformation_query,omitempty"`
+	TransformationQueryFile string         `yaml:"transformation_query_file,omitempty"` // relative to the pipeline definition
+	Notifications           *Notifications `yaml:"notifications,omitempty"`
 }
 
 // DataRef captures how a step references input/output data (e.g., S3 paths, table names).
@@ -49,6 +62,7 @@ type DataRef struct {
 	TableName string `yaml:"table_name,omitempty"` // e.g., staging.customer_activity
 	Host      string `yaml:"host,omitempty"`       // e.g., db host
 	Database  string `yaml:"database,omitempty"`
+	ConnID    string `yaml:"conn_id,omitempty"` // Airflow connection, e.g. postgres_default
 	// Add more fields if needed (e.g., secrets, authentication, etc.)
 }
 
@@ -63,6 +77,7 @@ type NotificationTarget struct {
 	Method     string   `yaml:"method"`
 	Recipients []string `yaml:"recipients,omitempty"`
 	Channel    string   `yaml:"channel,omitempty"`
+	ConnID     string   `yaml:"conn_id,omitempty"` // Airflow connection, e.g. smtp_default
 	// Add more fields depending on your notification channels.
 }
 
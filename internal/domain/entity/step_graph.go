@@ -0,0 +1,81 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrDuplicateStep   = errors.New("duplicate step")
+	ErrUnknownStep     = errors.New("unknown step")
+	ErrDependencyCycle = errors.New("dependency cycle")
+)
+
+// SortedSteps returns the steps ordered so that every step comes after the
+// steps it depends on. Steps keep their declared order where the dependencies
+// allow it. Unknown depends_on references and cycles are errors.
+func (p Pipeline) SortedSteps() ([]Step, error) {
+	byName := make(map[string]int, len(p.Steps))
+	for i, step := range p.Steps {
+		if _, exists := byName[step.Name]; exists {
+			return nil, fmt.Errorf("%w %q", ErrDuplicateStep, step.Name)
+		}
+		byName[step.Name] = i
+	}
+
+	for _, step := range p.Steps {
+		for _, dependency := range step.DependsOn {
+			if _, ok := byName[dependency]; !ok {
+				return nil, fmt.Errorf("step %q depends on %w %q", step.Name, ErrUnknownStep, dependency)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(p.Steps))
+	sorted := make([]Step, 0, len(p.Steps))
+	var path []string
+
+	// Depth first, so a step is only added once all of its dependencies are
+	var visit func(i int) error
+	visit = func(i int) error {
+		step := p.Steps[i]
+		switch state[i] {
+		case visited:
+			return nil
+		case visiting:
+			// Report the loop starting from where it closes
+			start := 0
+			for path[start] != step.Name {
+				start++
+			}
+			cycle := append(append([]string{}, path[start:]...), step.Name)
+			return fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(cycle, " -> "))
+		}
+
+		state[i] = visiting
+		path = append(path, step.Name)
+		for _, dependency := range step.DependsOn {
+			if err := visit(byName[dependency]); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[i] = visited
+
+		sorted = append(sorted, step)
+		return nil
+	}
+
+	for i := range p.Steps {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
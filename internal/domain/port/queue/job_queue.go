@@ -0,0 +1,56 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/entity"
+)
+
+var (
+	ErrQueueFull   = errors.New("job queue is full")
+	ErrQueueClosed = errors.New("job queue is closed")
+	ErrJobNotFound = errors.New("job not found")
+	ErrJobExists   = errors.New("job already exists")
+)
+
+// JobQueue holds jobs until a worker has finished processing them, and keeps
+// a history of finished jobs. Jobs that are dequeued but never settled are
+// delivered again.
+type JobQueue interface {
+	// Enqueue stores a job and makes it available to Dequeue. It returns
+	// ErrJobExists if a job with the same ID or dedup key is already known.
+	Enqueue(ctx context.Context, job *entity.Job) error
+
+	// Dequeue blocks until a job is available or the context is done.
+	Dequeue(ctx context.Context) (*entity.Job, error)
+
+	// Ack settles a dequeued job as succeeded or skipped so it is never
+	// delivered again.
+	Ack(ctx context.Context, id string, status entity.JobStatus, result *entity.JobResult) error
+
+	// Retry returns a failed job to the queue, to be delivered again no
+	// earlier than at.
+	Retry(ctx context.Context, id string, at time.Time, reason string, result *entity.JobResult) error
+
+	// DeadLetter settles a job that cannot be processed as failed. It is kept
+	// until redriven.
+	DeadLetter(ctx context.Context, id string, reason string, result *entity.JobResult) error
+
+	// Redrive moves a failed job back to the queue with its attempts reset.
+	Redrive(ctx context.Context, id string) error
+
+	Find(ctx context.Context, id string) (*entity.Job, error)
+
+	// FindByDedupKey returns the most recent job with the given dedup key.
+	// Jobs pruned from the history are still found by their dedup key for a
+	// while, without their payload and result.
+	FindByDedupKey(ctx context.Context, key string) (*entity.Job, error)
+
+	// List returns jobs newest first, optionally only those with the given
+	// status. A limit of 0 returns every job.
+	List(ctx context.Context, status entity.JobStatus, limit int) ([]*entity.Job, error)
+
+	Close() error
+}
@@ -2,13 +2,41 @@ package repository
 
 import (
 	"context"
+	"errors"
 
 	"github.com/Suhaibshah22/pipeweaver/internal/domain/entity"
 )
 
+// ErrFileNotFound is returned when a file does not exist at the requested revision.
+var ErrFileNotFound = errors.New("file not found")
+
+// ErrNothingToCommit is returned by CommitAndPush when no changes are staged.
+var ErrNothingToCommit = errors.New("nothing to commit")
+
 type GitRepository interface {
 	FindByPath(ctx context.Context, path string) (*entity.File, error)
 
+	// FindByPathAtRevision reads a file as it exists at a commit, branch or
+	// remote ref without touching the worktree.
+	FindByPathAtRevision(ctx context.Context, path string, revision string) (*entity.File, error)
+
+	// ListFilesAtRevision lists the paths of the files under a directory as
+	// they exist at a commit, branch or remote ref.
+	ListFilesAtRevision(ctx context.Context, dir string, revision string) ([]string, error)
+
+	// Fetch updates local refs from the remote using the given refspecs,
+	// e.g. "+refs/pull/1/head:refs/remotes/origin/pr/1".
+	Fetch(ctx context.Context, refSpecs ...string) error
+
+	// Pull brings the default branch up to date with the remote.
+	Pull(ctx context.Context) error
+
+	// ChangedFiles lists the files that differ between two commits. An empty
+	// or all-zero from revision is treated as an empty tree.
+	ChangedFiles(ctx context.Context, from string, to string) ([]entity.FileChange, error)
+
+	// CommitAndPush commits the staged changes and force-pushes the current
+	// branch, replacing whatever an earlier run pushed to it.
 	CommitAndPush(ctx context.Context, message string) error
 
 	CreateBranch(ctx context.Context, branchName string) error
@@ -19,6 +47,9 @@ type GitRepository interface {
 
 	Update(ctx context.Context, file *entity.File) error
 
+	// Delete removes a file or directory from the worktree and stages the removal.
+	Delete(ctx context.Context, path string) error
+
 	SwitchBackToMain(ctx context.Context) error
 
 	DeleteBranch(ctx context.Context, branchName string) error
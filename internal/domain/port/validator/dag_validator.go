@@ -0,0 +1,15 @@
+package validator
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrInvalidDAG = errors.New("invalid DAG")
+
+// DAGValidator checks a rendered DAG before it is committed. Validate returns
+// an error wrapping ErrInvalidDAG when the DAG is rejected, other errors mean
+// the check itself could not run.
+type DAGValidator interface {
+	Validate(ctx context.Context, dagPath string, content []byte) error
+}
@@ -0,0 +1,177 @@
+package schema
+
+import (
+	"fmt"
+
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/semver"
+)
+
+// StepTypes are the step types the DAG generator understands.
+var StepTypes = []string{"ingestion", "transformation", "sql", "bash", "python", "kubernetes_pod"}
+
+// DataRefTypes are the systems a step can read from or write to.
+var DataRefTypes = []string{"postgres", "mysql", "snowflake", "bigquery", "redshift", "s3", "gcs", "file"}
+
+// NotificationMethods are how notifications can be sent.
+var NotificationMethods = []string{"email", "slack", "slack_webhook"}
+
+// ScheduleTypes are how a schedule expression is interpreted.
+var ScheduleTypes = []string{"cron", "preset", "timedelta", "dataset"}
+
+// v2 is the version DAGs started running steps with real operators, fields
+// only that template renders are not available before it.
+const v2 = "v2.0"
+
+// Airflow only accepts these characters in dag_id and task_id.
+const airflowIDPattern = `^[A-Za-z0-9_.-]+$`
+
+// rule constrains one struct field, keyed by Go type name and YAML key.
+type rule struct {
+	description string
+	required    bool
+	enum        []string
+	pattern     string
+	patternHint string
+
+	// since is the version the field was added in, empty for fields that
+	// have always been there. enumSince does the same for enum values
+	since     string
+	enumSince map[string]string
+}
+
+func (r rule) supported(v semver.Version) bool {
+	return supportedSince(r.since, v)
+}
+
+// supportedSince reports whether something added in version since is
+// available in version v.
+func supportedSince(since string, v semver.Version) bool {
+	if since == "" {
+		return true
+	}
+	added, err := semver.Parse(since)
+	if err != nil {
+		panic(fmt.Sprintf("schema rule since %q: %v", since, err))
+	}
+	return v.Compare(added) >= 0
+}
+
+func (r rule) apply(s *Schema, v semver.Version) {
+	if r.description != "" {
+		s.Description = r.description
+	}
+	for _, value := range r.enum {
+		if supportedSince(r.enumSince[value], v) {
+			s.Enum = append(s.Enum, value)
+		}
+	}
+	s.Pattern = r.pattern
+	s.PatternHint = r.patternHint
+
+	if r.required {
+		switch s.Type {
+		case "string":
+			s.MinLength = 1
+		case "array":
+			s.MinItems = 1
+		}
+	}
+}
+
+var descriptions = map[string]string{
+	"UnifiedPipelineDefinition": "A pipeline definition, rendered into an Airflow DAG.",
+	"Pipeline":                  "Pipeline metadata and the steps it runs.",
+	"Owner":                     "Someone responsible for the pipeline.",
+	"Schedule":                  "When the pipeline runs.",
+	"Step":                      "A discrete stage of the pipeline, rendered as one Airflow task.",
+	"DataRef":                   "Data a step reads or writes.",
+	"Notifications":             "Who to notify when a step finishes.",
+	"NotificationTarget":        "Where and how a notification is sent.",
+	"Resources":                 "Platform resources used by the pipeline.",
+}
+
+var rules = map[string]rule{
+	"UnifiedPipelineDefinition.pipeline": {required: true},
+
+	"Pipeline.name": {
+		description: "Unique pipeline name, used as the Airflow dag_id.",
+		required:    true,
+		pattern:     airflowIDPattern,
+		patternHint: "may only contain letters, digits, '_', '.' and '-', it is used as the Airflow dag_id",
+	},
+	"Pipeline.version":       {description: "Version of the pipeline definition format, selects the DAG template.", required: true},
+	"Pipeline.domain":        {description: "Business domain the pipeline belongs to."},
+	"Pipeline.description":   {description: "What the pipeline does."},
+	"Pipeline.parameters":    {description: "Free-form parameters available to templates."},
+	"Pipeline.steps":         {description: "Steps run by the pipeline.", required: true},
+	"Pipeline.notifications": {description: "Who to notify when a run of the pipeline finishes.", since: v2},
+
+	"Owner.name":  {required: true},
+	"Owner.email": {},
+
+	"Schedule.type": {
+		description: "How expression is interpreted, or dataset to run when the datasets are updated. Inferred from expression and datasets when left out.",
+		enum:        ScheduleTypes,
+		enumSince:   map[string]string{"timedelta": v2, "dataset": v2},
+	},
+	"Schedule.expression": {description: "A five field cron expression, a preset such as @daily or a timedelta such as 1d12h or 30m."},
+	"Schedule.datasets": {
+		description: "URIs of the datasets whose updates trigger a dataset schedule, e.g. s3://lake/orders.",
+		since:       v2,
+	},
+	"Schedule.timezone": {
+		description: "IANA time zone the schedule and its dates are in, e.g. Europe/Amsterdam. Airflow's default time zone when left out.",
+		since:       v2,
+	},
+	"Schedule.start_date": {
+		description: "First date the pipeline runs for, 2024-01-01 or 2024-01-01T06:00. 2023-01-01 when left out.",
+		since:       v2,
+	},
+	"Schedule.end_date":        {description: "Last date the pipeline runs for, in the same format as start_date.", since: v2},
+	"Schedule.catchup":         {description: "Whether runs missed since start_date are run, false when left out.", since: v2},
+	"Schedule.max_active_runs": {description: "How many runs of the pipeline may run at once, Airflow's default when left out.", since: v2},
+
+	"Step.name": {
+		description: "Step name, unique within the pipeline and used as the Airflow task_id.",
+		required:    true,
+		pattern:     airflowIDPattern,
+		patternHint: "may only contain letters, digits, '_', '.' and '-', it is used as the Airflow task_id",
+	},
+	"Step.type":                 {description: "What kind of work the step does.", required: true, enum: StepTypes},
+	"Step.description":          {description: "What the step does."},
+	"Step.depends_on":           {description: "Steps that must finish before this one starts."},
+	"Step.inputs":               {description: "Data the step reads."},
+	"Step.outputs":              {description: "Data the step writes."},
+	"Step.config":               {description: "Keyword arguments of the step's Airflow operator, config.operator replaces the operator picked by type."},
+	"Step.transformation_query": {description: "SQL run by transformation steps."},
+	"Step.transformation_query_file": {
+		description: "SQL file run by transformation steps instead of transformation_query, relative to the pipeline definition.",
+		since:       v2,
+	},
+	"Step.notifications": {description: "Who to notify when the step finishes.", since: v2},
+
+	"DataRef.name":       {required: true},
+	"DataRef.type":       {required: true, enum: DataRefTypes},
+	"DataRef.path":       {description: "Object storage or file path, e.g. s3://bucket/prefix."},
+	"DataRef.table_name": {description: "Fully qualified table, e.g. staging.customer_activity."},
+	"DataRef.host":       {description: "Database host."},
+	"DataRef.database":   {description: "Database name."},
+	"DataRef.conn_id":    {description: "Airflow connection used to reach the data, <type>_default when left out.", since: v2},
+
+	"Notifications.on_success": {description: "Notified when the pipeline or step succeeds."},
+	"Notifications.on_failure": {description: "Notified when the pipeline or step fails."},
+
+	"NotificationTarget.method": {
+		description: "email, slack through a Slack API connection, or slack_webhook through an incoming webhook connection.",
+		required:    true,
+		enum:        NotificationMethods,
+	},
+	"NotificationTarget.recipients": {description: "Email addresses, required by email notifications."},
+	"NotificationTarget.channel":    {description: "Slack channel, e.g. #data-alerts, required by slack notifications."},
+	"NotificationTarget.conn_id": {
+		description: "Airflow connection to send with, smtp_default, slack_api_default or slack_default when left out.",
+	},
+
+	"Resources.compute_cluster":  {description: "Cluster the pipeline runs on."},
+	"Resources.storage_location": {description: "Where the pipeline stores intermediate data."},
+}
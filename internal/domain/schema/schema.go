@@ -0,0 +1,202 @@
+// Package schema generates the JSON Schema of pipeline definitions from the
+// entity types. The same schema is served to editors and enforced by the
+// validation package, so documentation and validation cannot drift apart.
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/entity"
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/semver"
+)
+
+// Versions are the pipeline versions a schema is published for, those that
+// changed the definition format. Other versions use the newest schema of the
+// same major version below them, so 2.0.0 and 2.1 use v2.0.
+var Versions = []string{"v1.0", "v2.0"}
+
+var ErrUnknownVersion = errors.New("unknown pipeline version")
+
+const draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is the subset of JSON Schema used to describe pipeline definitions.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinLength            int                `json:"minLength,omitempty"`
+	MinItems             int                `json:"minItems,omitempty"`
+
+	// PatternHint explains Pattern in validation messages.
+	PatternHint string `json:"-"`
+
+	// Unsupported are the properties of later versions, by the version they
+	// were added in, for validation messages.
+	Unsupported map[string]string `json:"-"`
+}
+
+// Property returns the schema of a nested property, or nil if there is none.
+func (s *Schema) Property(names ...string) *Schema {
+	for _, name := range names {
+		if s == nil {
+			return nil
+		}
+		s = s.Properties[name]
+	}
+	return s
+}
+
+// patterns caches compiled patterns by their source, validation matches the
+// same few patterns against every name in every definition.
+var patterns sync.Map
+
+// MatchesPattern reports whether a string value matches Pattern, compiling
+// each pattern only once. Patterns come from the rules, so an invalid one
+// panics like regexp.MustCompile.
+func (s *Schema) MatchesPattern(value string) bool {
+	if s.Pattern == "" {
+		return true
+	}
+	re, ok := patterns.Load(s.Pattern)
+	if !ok {
+		re, _ = patterns.LoadOrStore(s.Pattern, regexp.MustCompile(s.Pattern))
+	}
+	return re.(*regexp.Regexp).MatchString(value)
+}
+
+// For returns the schema of pipeline definitions of the given version, that
+// of the newest published version of the same major version not above it.
+func For(version string) (*Schema, error) {
+	published, v, err := Resolve(version)
+	if err != nil {
+		return nil, err
+	}
+
+	root := generate(reflect.TypeOf(entity.UnifiedPipelineDefinition{}), v)
+	root.Schema = draft
+	root.ID = "https://pipeweaver.dev/schemas/pipeline/" + published + ".json"
+	root.Title = "pipeweaver pipeline definition " + published
+	return root, nil
+}
+
+// Resolve returns the published schema version a pipeline version uses: the
+// newest one of the same major version that is not above it.
+func Resolve(version string) (string, semver.Version, error) {
+	c, err := semver.ParseConstraint(version)
+	if err != nil {
+		return "", semver.Version{}, fmt.Errorf("%w: %w", ErrUnknownVersion, err)
+	}
+
+	best, bestVersion := "", semver.Version{}
+	for _, published := range Versions {
+		v, err := semver.Parse(published)
+		if err != nil {
+			panic(fmt.Sprintf("schema version %q: %v", published, err))
+		}
+		if v.Major == c.Min.Major && v.Compare(c.Min) <= 0 && (best == "" || v.Compare(bestVersion) > 0) {
+			best, bestVersion = published, v
+		}
+	}
+	if best == "" {
+		return "", semver.Version{}, fmt.Errorf("%w %q, expected one of %s", ErrUnknownVersion, version, strings.Join(Versions, ", "))
+	}
+	return best, bestVersion, nil
+}
+
+// Latest returns the schema of the newest pipeline version.
+func Latest() *Schema {
+	s, _ := For(Versions[len(Versions)-1])
+	return s
+}
+
+// generate describes a Go type as of version v, applying the rules for each
+// struct field.
+func generate(t reflect.Type, v semver.Version) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: generate(t.Elem(), v)}
+	case reflect.Map:
+		// Free-form, e.g. pipeline parameters
+		return &Schema{Type: "object"}
+	case reflect.Struct:
+		closed := false
+		s := &Schema{
+			Type:                 "object",
+			Description:          descriptions[t.Name()],
+			Properties:           map[string]*Schema{},
+			AdditionalProperties: &closed,
+		}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := FieldName(field)
+			if name == "" {
+				continue
+			}
+
+			r, hasRule := rules[t.Name()+"."+name]
+			if hasRule && !r.supported(v) {
+				if s.Unsupported == nil {
+					s.Unsupported = map[string]string{}
+				}
+				s.Unsupported[name] = r.since
+				continue
+			}
+
+			property := generate(field.Type, v)
+			if hasRule {
+				r.apply(property, v)
+				if r.required {
+					s.Required = append(s.Required, name)
+				}
+			}
+			s.Properties[name] = property
+		}
+		return s
+	default:
+		// Anything goes, e.g. step config
+		return &Schema{}
+	}
+}
+
+// FieldName returns the YAML key of a struct field, or an empty string if the
+// field is not decoded.
+func FieldName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
@@ -0,0 +1,140 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestForResolvesVersions(t *testing.T) {
+	tests := []struct {
+		version string
+		wantID  string
+		wantErr bool
+	}{
+		{"v1.0", "https://pipeweaver.dev/schemas/pipeline/v1.0.json", false},
+		{"1.0.0", "https://pipeweaver.dev/schemas/pipeline/v1.0.json", false},
+		{"1.4", "https://pipeweaver.dev/schemas/pipeline/v1.0.json", false},
+		{"v2.0", "https://pipeweaver.dev/schemas/pipeline/v2.0.json", false},
+		{"2.1.3", "https://pipeweaver.dev/schemas/pipeline/v2.0.json", false},
+		{"v0.9", "", true},
+		{"3", "", true},
+		{"latest", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			s, err := For(tt.version)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnknownVersion) {
+					t.Errorf("For(%q) = %v, want ErrUnknownVersion", tt.version, err)
+				}
+				return
+			}
+			if err != nil || s.ID != tt.wantID {
+				t.Errorf("For(%q) = %v, %v, want %s", tt.version, s, err, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestForAppliesVersionRules(t *testing.T) {
+	v1, err := For("v1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, err := For("v2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path        []string
+		unsupported string // the key missing from v1.0
+	}{
+		{[]string{"pipeline"}, "notifications"},
+		{[]string{"pipeline", "schedule"}, "timezone"},
+		{[]string{"pipeline", "schedule"}, "datasets"},
+		{[]string{"pipeline", "steps", "items"}, "transformation_query_file"},
+		{[]string{"pipeline", "steps", "items", "inputs", "items"}, "conn_id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.unsupported, func(t *testing.T) {
+			old, current := property(v1, tt.path), property(v2, tt.path)
+			if _, ok := old.Properties[tt.unsupported]; ok {
+				t.Errorf("v1.0 has %s", tt.unsupported)
+			}
+			if since := old.Unsupported[tt.unsupported]; since != "v2.0" {
+				t.Errorf("v1.0 reports %s as added in %q, want v2.0", tt.unsupported, since)
+			}
+			if _, ok := current.Properties[tt.unsupported]; !ok {
+				t.Errorf("v2.0 has no %s", tt.unsupported)
+			}
+		})
+	}
+
+	// Enum values are added per version as well
+	if enum := v1.Property("pipeline", "schedule", "type").Enum; !slices.Equal(enum, []string{"cron", "preset"}) {
+		t.Errorf("v1.0 schedule types = %v, want cron, preset", enum)
+	}
+	if enum := v2.Property("pipeline", "schedule", "type").Enum; !slices.Equal(enum, ScheduleTypes) {
+		t.Errorf("v2.0 schedule types = %v, want %v", enum, ScheduleTypes)
+	}
+}
+
+func TestForDescribesDefinitions(t *testing.T) {
+	s := Latest()
+	if s.ID != "https://pipeweaver.dev/schemas/pipeline/"+Versions[len(Versions)-1]+".json" || s.Schema != draft {
+		t.Errorf("Latest() = %s (%s), want the newest version", s.ID, s.Schema)
+	}
+
+	pipeline := s.Property("pipeline")
+	if !slices.Contains(pipeline.Required, "name") || !slices.Contains(pipeline.Required, "steps") {
+		t.Errorf("pipeline requires %v, want name and steps", pipeline.Required)
+	}
+	if steps := pipeline.Property("steps"); steps.MinItems != 1 {
+		t.Errorf("steps has minItems %d, want 1", steps.MinItems)
+	}
+	if pipeline.AdditionalProperties == nil || *pipeline.AdditionalProperties {
+		t.Error("pipeline allows unknown keys")
+	}
+	if s.Property("pipeline", "parameters").AdditionalProperties != nil {
+		t.Error("pipeline parameters are not free-form")
+	}
+
+	name := pipeline.Property("name")
+	for value, want := range map[string]bool{"daily_orders-v2.1": true, "daily orders": false, "": false} {
+		if got := name.MatchesPattern(value); got != want {
+			t.Errorf("name %q matches %s = %t, want %t", value, name.Pattern, got, want)
+		}
+	}
+
+	// Hints and unsupported keys are only for validation messages
+	v1, err := For("v1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := json.Marshal(v1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(encoded), `"$schema":"`+draft+`"`) ||
+		strings.Contains(string(encoded), "Unsupported") || strings.Contains(string(encoded), "PatternHint") {
+		t.Errorf("schema JSON = %s, want $schema without validation hints", encoded)
+	}
+}
+
+// property walks a schema, "items" stepping into array items.
+func property(s *Schema, path []string) *Schema {
+	for _, name := range path {
+		if name == "items" {
+			s = s.Items
+			continue
+		}
+		s = s.Property(name)
+	}
+	return s
+}
@@ -0,0 +1,167 @@
+// Package semver parses the versions pipelines ask for and the versions DAG
+// templates and schemas are published for, so both resolve them alike.
+package semver
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalid = errors.New("invalid version")
+	ErrNoMatch = errors.New("no version matches")
+)
+
+// Version is a semantic version. Missing components are zero, so v1.0 is 1.0.0.
+type Version struct {
+	Major, Minor, Patch int
+}
+
+func (v Version) Compare(other Version) int {
+	if c := cmp.Compare(v.Major, other.Major); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.Minor, other.Minor); c != 0 {
+		return c
+	}
+	return cmp.Compare(v.Patch, other.Patch)
+}
+
+// Parse parses a published version, e.g. v1.0 or 1.2.3.
+func Parse(s string) (Version, error) {
+	parts, err := versionParts(s, false)
+	if err != nil {
+		return Version{}, err
+	}
+	return partsVersion(parts), nil
+}
+
+// Constraint selects published versions.
+type Constraint struct {
+	// Min is inclusive and Max exclusive, a zero Max leaves the range open
+	Min, Max Version
+}
+
+func (c Constraint) Matches(v Version) bool {
+	return v.Compare(c.Min) >= 0 && (c.Max == Version{} || v.Compare(c.Max) < 0)
+}
+
+// ParseConstraint parses the version a pipeline asks for:
+//
+//	1.2.3   exactly 1.2.3
+//	v1.2    any 1.2.x, like 1.2.x or 1.2.*
+//	1.x     any 1.x.x, like v1 or 1.*
+//	~1.2.3  1.2.3 or a later patch, below 1.3.0
+//	^1.2    1.2.0 or later, below 2.0.0
+func ParseConstraint(s string) (Constraint, error) {
+	s = strings.TrimSpace(s)
+
+	switch {
+	case strings.HasPrefix(s, "~"):
+		parts, err := versionParts(s[1:], false)
+		if err != nil {
+			return Constraint{}, err
+		}
+		min := partsVersion(parts)
+		if len(parts) == 1 {
+			return Constraint{Min: min, Max: Version{Major: min.Major + 1}}, nil
+		}
+		return Constraint{Min: min, Max: Version{Major: min.Major, Minor: min.Minor + 1}}, nil
+	case strings.HasPrefix(s, "^"):
+		parts, err := versionParts(s[1:], false)
+		if err != nil {
+			return Constraint{}, err
+		}
+		min := partsVersion(parts)
+		return Constraint{Min: min, Max: Version{Major: min.Major + 1}}, nil
+	}
+
+	// Components left out or given as x match any value
+	parts, err := versionParts(s, true)
+	if err != nil {
+		return Constraint{}, err
+	}
+	min := partsVersion(parts)
+	switch len(parts) {
+	case 1:
+		return Constraint{Min: min, Max: Version{Major: min.Major + 1}}, nil
+	case 2:
+		return Constraint{Min: min, Max: Version{Major: min.Major, Minor: min.Minor + 1}}, nil
+	}
+	return Constraint{Min: min, Max: Version{Major: min.Major, Minor: min.Minor, Patch: min.Patch + 1}}, nil
+}
+
+// Resolve returns the highest of the published versions that the version a
+// pipeline asks for matches, as spelled in published. Published versions that
+// do not parse are ignored. Pipelines, DAG templates and validation all
+// resolve versions with it, so they agree on which versions exist.
+func Resolve(requested string, published []string) (string, error) {
+	c, err := ParseConstraint(requested)
+	if err != nil {
+		return "", err
+	}
+
+	best, bestVersion := "", Version{}
+	for _, spelled := range published {
+		v, err := Parse(spelled)
+		if err != nil {
+			continue
+		}
+		if c.Matches(v) && (best == "" || v.Compare(bestVersion) >= 0) {
+			best, bestVersion = spelled, v
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("%w %q, available versions: %s", ErrNoMatch, requested, strings.Join(published, ", "))
+	}
+	return best, nil
+}
+
+// versionParts splits a version into its numeric components, dropping a
+// leading v. With wildcards, trailing x or * components are dropped too.
+func versionParts(s string, wildcards bool) ([]int, error) {
+	components := strings.Split(strings.TrimPrefix(strings.TrimPrefix(s, "v"), "V"), ".")
+	if len(components) > 3 {
+		return nil, fmt.Errorf("%w %q, expected at most major.minor.patch", ErrInvalid, s)
+	}
+
+	parts := make([]int, 0, len(components))
+	for i, component := range components {
+		if wildcards && (component == "x" || component == "X" || component == "*") {
+			if i == 0 {
+				return nil, fmt.Errorf("%w %q, the major version is required", ErrInvalid, s)
+			}
+			for _, rest := range components[i:] {
+				if rest != "x" && rest != "X" && rest != "*" {
+					return nil, fmt.Errorf("%w %q, only trailing components can be x", ErrInvalid, s)
+				}
+			}
+			break
+		}
+
+		n, err := strconv.Atoi(component)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%w %q, expected a version such as 1.0.0, v1.0, 1.x or ~1.2", ErrInvalid, s)
+		}
+		parts = append(parts, n)
+	}
+	return parts, nil
+}
+
+func partsVersion(parts []int) Version {
+	var v Version
+	for i, n := range parts {
+		switch i {
+		case 0:
+			v.Major = n
+		case 1:
+			v.Minor = n
+		case 2:
+			v.Patch = n
+		}
+	}
+	return v
+}
@@ -0,0 +1,112 @@
+package semver
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Version
+		wantErr bool
+	}{
+		{"1.0.0", Version{1, 0, 0}, false},
+		{"v1.0", Version{1, 0, 0}, false},
+		{"V2", Version{2, 0, 0}, false},
+		{"1.2.3", Version{1, 2, 3}, false},
+		{"1.x", Version{}, true},
+		{"1.2.3.4", Version{}, true},
+		{"", Version{}, true},
+		{"one", Version{}, true},
+		{"1.-1", Version{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalid) {
+					t.Errorf("Parse(%q) = %v, %v, want ErrInvalid", tt.input, got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Parse(%q) = %v, %v, want %v", tt.input, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseConstraint(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Constraint
+		wantErr bool
+	}{
+		{"1.0.0", Constraint{Min: Version{1, 0, 0}, Max: Version{1, 0, 1}}, false},
+		{"v1.0", Constraint{Min: Version{1, 0, 0}, Max: Version{1, 1, 0}}, false},
+		{"1.2.x", Constraint{Min: Version{1, 2, 0}, Max: Version{1, 3, 0}}, false},
+		{"1.x", Constraint{Min: Version{1, 0, 0}, Max: Version{2, 0, 0}}, false},
+		{"1.*.*", Constraint{Min: Version{1, 0, 0}, Max: Version{2, 0, 0}}, false},
+		{"1", Constraint{Min: Version{1, 0, 0}, Max: Version{2, 0, 0}}, false},
+		{"~1.2", Constraint{Min: Version{1, 2, 0}, Max: Version{1, 3, 0}}, false},
+		{"~1.2.3", Constraint{Min: Version{1, 2, 3}, Max: Version{1, 3, 0}}, false},
+		{"~1", Constraint{Min: Version{1, 0, 0}, Max: Version{2, 0, 0}}, false},
+		{"^1.2", Constraint{Min: Version{1, 2, 0}, Max: Version{2, 0, 0}}, false},
+		{" v2.0 ", Constraint{Min: Version{2, 0, 0}, Max: Version{2, 1, 0}}, false},
+		{"x", Constraint{}, true},
+		{"1.x.2", Constraint{}, true},
+		{"~1.x", Constraint{}, true},
+		{"^", Constraint{}, true},
+		{">=1.0", Constraint{}, true},
+		{"latest", Constraint{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseConstraint(tt.input)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalid) {
+					t.Errorf("ParseConstraint(%q) = %v, %v, want ErrInvalid", tt.input, got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ParseConstraint(%q) = %v, %v, want %v", tt.input, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	published := []string{"v1.0", "1.2.0", "v1.2.5", "v2.0", "2.1", "not-a-version"}
+
+	tests := []struct {
+		requested string
+		want      string
+		wantErr   error
+	}{
+		{"1.0.0", "v1.0", nil},
+		{"v1.0", "v1.0", nil},
+		{"1.x", "v1.2.5", nil},
+		{"1", "v1.2.5", nil},
+		{"~1.2", "v1.2.5", nil},
+		{"^1.0", "v1.2.5", nil},
+		{"v2", "2.1", nil},
+		{"2.0.0", "v2.0", nil},
+		{"1.5", "", ErrNoMatch},
+		{"~1.3", "", ErrNoMatch},
+		{"3.x", "", ErrNoMatch},
+		{"latest", "", ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.requested, func(t *testing.T) {
+			got, err := Resolve(tt.requested, published)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("Resolve(%q) = %q, %v, want %q, %v", tt.requested, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SchedulePresets are the schedule shorthands Airflow accepts in place of a cron expression.
+var SchedulePresets = []string{"@once", "@continuous", "@hourly", "@daily", "@weekly", "@monthly", "@quarterly", "@yearly", "@annually"}
+
+type cronField struct {
+	name     string
+	min, max int
+	names    []string // aliases for min, min+1, ...
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}},
+	// 7 is Sunday as well
+	{name: "day of week", min: 0, max: 7, names: []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}},
+}
+
+// ValidateCron checks a standard five field cron expression
+// (minute hour day-of-month month day-of-week).
+func ValidateCron(expression string) error {
+	if strings.TrimSpace(expression) == "" {
+		return errors.New("cron expression is required")
+	}
+
+	fields := strings.Fields(expression)
+	if len(fields) != len(cronFields) {
+		return fmt.Errorf("cron expression %q has %d fields, expected 5 (minute hour day-of-month month day-of-week)", expression, len(fields))
+	}
+
+	for i, field := range fields {
+		if err := cronFields[i].validate(field); err != nil {
+			return fmt.Errorf("cron expression %q: %w", expression, err)
+		}
+	}
+	return nil
+}
+
+// validate checks one field: a comma separated list of *, values or ranges,
+// each optionally followed by /step.
+func (f cronField) validate(field string) error {
+	for _, item := range strings.Split(field, ",") {
+		rangePart, step, hasStep := strings.Cut(item, "/")
+		if hasStep {
+			n, err := strconv.Atoi(step)
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid step %q in %s field", step, f.name)
+			}
+		}
+
+		if rangePart == "*" {
+			continue
+		}
+
+		from, to, isRange := strings.Cut(rangePart, "-")
+		start, err := f.value(from)
+		if err != nil {
+			return err
+		}
+		if !isRange {
+			continue
+		}
+
+		end, err := f.value(to)
+		if err != nil {
+			return err
+		}
+		if start > end {
+			return fmt.Errorf("range %q in %s field is backwards", rangePart, f.name)
+		}
+	}
+	return nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q in %s field", s, f.name)
+	}
+	if n < f.min || n > f.max {
+		return 0, fmt.Errorf("value %d in %s field is out of range %d-%d", n, f.name, f.min, f.max)
+	}
+	return n, nil
+}
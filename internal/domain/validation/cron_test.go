@@ -0,0 +1,43 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateCron(t *testing.T) {
+	tests := []struct {
+		expression string
+		wantErr    string // empty when valid
+	}{
+		{"* * * * *", ""},
+		{"0 6 * * *", ""},
+		{"*/15 9-17 * * 1-5", ""},
+		{"0 0 1,15 * *", ""},
+		{"0 12 * jan-mar sun", ""},
+		{"0 0 * * 7", ""},
+		{"30 2 31 12 SAT", ""},
+		{"", "cron expression is required"},
+		{"0 6 * *", "has 4 fields, expected 5"},
+		{"0 6 * * * *", "has 6 fields, expected 5"},
+		{"60 * * * *", "value 60 in minute field is out of range 0-59"},
+		{"0 0 0 * *", "value 0 in day of month field is out of range 1-31"},
+		{"0 0 * 13 *", "value 13 in month field is out of range 1-12"},
+		{"*/0 * * * *", `invalid step "0" in minute field`},
+		{"0 17-9 * * *", `range "17-9" in hour field is backwards`},
+		{"0 0 * * MONDAY", `invalid value "MONDAY" in day of week field`},
+		{"@daily * * * *", `invalid value "@daily" in minute field`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			err := ValidateCron(tt.expression)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("ValidateCron(%q) = %v, want nil", tt.expression, err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("ValidateCron(%q) = %v, want an error containing %q", tt.expression, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// position is where a node starts in the YAML document.
+type position struct {
+	Line   int
+	Column int
+}
+
+// positions maps field paths such as pipeline.steps[1].name to where their
+// value starts in the document.
+type positions map[string]position
+
+func indexPositions(root *yaml.Node) positions {
+	index := positions{}
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		index.walk("", root.Content[0])
+	}
+	return index
+}
+
+func (index positions) walk(path string, node *yaml.Node) {
+	index[path] = position{Line: node.Line, Column: node.Column}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if path != "" {
+				key = path + "." + key
+			}
+			index.walk(key, node.Content[i+1])
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			index.walk(fmt.Sprintf("%s[%d]", path, i), item)
+		}
+	case yaml.AliasNode:
+		if node.Alias != nil {
+			index.walk(path, node.Alias)
+			index[path] = position{Line: node.Line, Column: node.Column}
+		}
+	}
+}
+
+// lookup returns the position of path, or of its closest ancestor in the
+// document when the field itself is missing.
+func (index positions) lookup(path string) (int, int) {
+	for {
+		if pos, ok := index[path]; ok {
+			return pos.Line, pos.Column
+		}
+
+		cut := strings.LastIndexAny(path, ".[")
+		if cut < 0 {
+			pos := index[""]
+			return pos.Line, pos.Column
+		}
+		path = path[:cut]
+	}
+}
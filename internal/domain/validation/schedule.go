@@ -0,0 +1,99 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // time zones are checked the same on hosts without a zoneinfo database
+
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/entity"
+)
+
+// timedeltaPattern matches intervals such as 1d12h, 30m or 90s.
+var timedeltaPattern = regexp.MustCompile(`^(?:(\d+)d)?(?:(\d+)h)?(?:(\d+)m)?(?:(\d+)s)?$`)
+
+// scheduleDateLayouts are the formats start_date and end_date can be written in.
+var scheduleDateLayouts = []string{"2006-01-02", "2006-01-02T15:04", "2006-01-02T15:04:05"}
+
+// Timedelta is the interval of a timedelta schedule.
+type Timedelta struct {
+	Days, Hours, Minutes, Seconds int
+}
+
+// ScheduleType returns the type of a schedule, inferring it from the
+// expression and datasets when it is not set. It is empty for a schedule that
+// never runs by itself.
+func ScheduleType(schedule *entity.Schedule) string {
+	switch {
+	case schedule == nil:
+		return ""
+	case schedule.Type != "":
+		return schedule.Type
+	case schedule.Expression == "" && len(schedule.Datasets) > 0:
+		return "dataset"
+	}
+	return expressionType(schedule.Expression)
+}
+
+// expressionType is the schedule type an expression is written as.
+func expressionType(expression string) string {
+	switch {
+	case expression == "":
+		return ""
+	case strings.HasPrefix(expression, "@"):
+		return "preset"
+	case timedeltaPattern.MatchString(expression):
+		return "timedelta"
+	}
+	return "cron"
+}
+
+// ParseTimedelta parses an interval of days, hours, minutes and seconds such
+// as 1d12h or 30m.
+func ParseTimedelta(expression string) (Timedelta, error) {
+	match := timedeltaPattern.FindStringSubmatch(expression)
+	if expression == "" || match == nil {
+		return Timedelta{}, fmt.Errorf("timedelta %q must be days, hours, minutes and seconds, e.g. 1d12h or 30m", expression)
+	}
+
+	var parts [4]int
+	for i, part := range match[1:] {
+		if part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return Timedelta{}, fmt.Errorf("timedelta %q: %w", expression, err)
+		}
+		parts[i] = n
+	}
+	if parts == [4]int{} {
+		return Timedelta{}, fmt.Errorf("timedelta %q must be longer than zero", expression)
+	}
+	return Timedelta{Days: parts[0], Hours: parts[1], Minutes: parts[2], Seconds: parts[3]}, nil
+}
+
+// ParseScheduleDate parses a start_date or end_date. The time is in the
+// schedule's time zone, so it is returned without one, as UTC.
+func ParseScheduleDate(value string) (time.Time, error) {
+	for _, layout := range scheduleDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("date %q must be written as 2024-01-01, 2024-01-01T06:00 or 2024-01-01T06:00:00", value)
+}
+
+// ValidateTimezone checks that a time zone is an IANA name Airflow understands.
+func ValidateTimezone(name string) error {
+	if name == "Local" {
+		return errors.New(`time zone "Local" depends on the Airflow host, use an IANA name such as Europe/Amsterdam`)
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return fmt.Errorf("unknown time zone %q, expected an IANA name such as Europe/Amsterdam or UTC", name)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package validation
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/schema"
+
+	"gopkg.in/yaml.v3"
+)
+
+// checkSchema walks a YAML node alongside the schema describing it and reports
+// missing required fields, unknown keys, values outside an enum and values not
+// matching a pattern. Unknown keys are only warnings unless strict is set.
+func (v *validator) checkSchema(node *yaml.Node, s *schema.Schema, path string, strict bool) {
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+
+	switch {
+	case s.Type == "object" && node.Kind == yaml.MappingNode:
+		v.checkObject(node, s, path, strict)
+	case s.Type == "array" && node.Kind == yaml.SequenceNode:
+		if len(node.Content) < s.MinItems {
+			v.problemAt(node, path, "%s must not be empty", fieldLabel(path))
+		}
+		if s.Items != nil {
+			for i, item := range node.Content {
+				v.checkSchema(item, s.Items, fmt.Sprintf("%s[%d]", path, i), strict)
+			}
+		}
+	case node.Kind == yaml.ScalarNode:
+		v.checkScalar(node, s, path)
+	}
+}
+
+func (v *validator) checkObject(node *yaml.Node, s *schema.Schema, path string, strict bool) {
+	present := map[string]*yaml.Node{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		keyPath := joinPath(path, key.Value)
+
+		property, known := s.Properties[key.Value]
+		if !known {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				v.unknownKey(key, keyPath, s, strict)
+			}
+			continue
+		}
+
+		present[key.Value] = value
+		v.checkSchema(value, property, keyPath, strict)
+	}
+
+	for _, name := range s.Required {
+		value, ok := present[name]
+		if !ok || value.Tag == "!!null" {
+			v.problemAt(node, joinPath(path, name), "%s is required", name)
+		}
+	}
+}
+
+func (v *validator) checkScalar(node *yaml.Node, s *schema.Schema, path string) {
+	if node.Tag == "!!null" {
+		return
+	}
+	value := node.Value
+	label := fieldLabel(path)
+
+	switch {
+	case value == "" && (s.MinLength > 0 || len(s.Enum) > 0):
+		message := fmt.Sprintf("%s is required", label)
+		if len(s.Enum) > 0 {
+			message += ", one of " + strings.Join(s.Enum, ", ")
+		}
+		v.problemAt(node, path, "%s", message)
+	case len(s.Enum) > 0 && !slices.Contains(s.Enum, value):
+		v.problemAt(node, path, "unknown %s %q, expected one of %s", label, value, strings.Join(s.Enum, ", "))
+	case !s.MatchesPattern(value):
+		hint := s.PatternHint
+		if hint == "" {
+			hint = "must match " + s.Pattern
+		}
+		v.problemAt(node, path, "%s %q %s", label, value, hint)
+	}
+}
+
+// unknownKey reports a key the schema does not know, suggesting the closest known one.
+func (v *validator) unknownKey(key *yaml.Node, path string, s *schema.Schema, strict bool) {
+	known := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		known = append(known, name)
+	}
+	sort.Strings(known)
+
+	message := fmt.Sprintf("unknown field %q", key.Value)
+	if !strict {
+		message += " is ignored"
+	}
+	if since, ok := s.Unsupported[key.Value]; ok {
+		message += fmt.Sprintf(", it needs pipeline version %s or later", since)
+	} else if suggestion := suggest(key.Value, known); suggestion != "" {
+		message += fmt.Sprintf(", did you mean %s?", suggestion)
+	}
+
+	problem := Problem{Path: path, Line: key.Line, Column: key.Column, Message: message}
+	if strict {
+		v.problems = append(v.problems, problem)
+	} else {
+		v.warnings = append(v.warnings, problem)
+	}
+}
+
+// problemAt records a problem positioned at node.
+func (v *validator) problemAt(node *yaml.Node, path, format string, args ...any) {
+	v.problems = append(v.problems, Problem{
+		Path:    path,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// fieldLabel is the last key in a path, e.g. "type" for pipeline.steps[0].type.
+func fieldLabel(path string) string {
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		path = path[i+1:]
+	}
+	if i := strings.Index(path, "["); i >= 0 {
+		path = path[:i]
+	}
+	return path
+}
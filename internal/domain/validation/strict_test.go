@@ -0,0 +1,148 @@
+package validation
+
+import (
+	"fmt"
+	"testing"
+)
+
+// readmeSample is the README example as it was first published, reading its
+// Postgres input with table instead of table_name.
+const readmeSample = `pipeline:
+  name: "user-subscriptions"
+  version: "%s"
+  steps:
+    - name: "extract-and-load"
+      type: "ingestion"
+      inputs:
+        - name: "postgres-source"
+          type: "postgres"
+          host: "subscription-db.exampled.com"
+          database: "subscriptions"
+          table: "user_subscriptions"
+`
+
+func TestValidateUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name         string
+		version      string
+		opts         Options
+		wantProblems []wantProblem
+		wantWarnings []wantProblem
+	}{
+		{
+			name:    "strict",
+			version: "2.0.0",
+			wantProblems: []wantProblem{
+				{"pipeline.steps[0].inputs[0].table", 12, 11, `unknown field "table", did you mean table_name?`},
+			},
+		},
+		{
+			name:    "lenient version",
+			version: "1.0.0",
+			opts:    Options{LenientVersions: []string{"v1.0"}},
+			wantWarnings: []wantProblem{
+				{"pipeline.steps[0].inputs[0].table", 12, 11, `unknown field "table" is ignored, did you mean table_name?`},
+			},
+		},
+		{
+			name:    "lenient version resolves like the pipeline version",
+			version: "v1.0",
+			opts:    Options{LenientVersions: []string{"1.x"}},
+			wantWarnings: []wantProblem{
+				{"pipeline.steps[0].inputs[0].table", 12, 11, `unknown field "table" is ignored`},
+			},
+		},
+		{
+			name:    "other versions stay strict",
+			version: "v2.0",
+			opts:    Options{LenientVersions: []string{"v1.0"}},
+			wantProblems: []wantProblem{
+				{"pipeline.steps[0].inputs[0].table", 12, 11, `unknown field "table", did you mean table_name?`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems, warnings := validateProblems(t, fmt.Sprintf(readmeSample, tt.version), tt.opts)
+			checkProblems(t, "problems", problems, tt.wantProblems)
+			checkProblems(t, "warnings", warnings, tt.wantWarnings)
+		})
+	}
+}
+
+func TestValidateSuggestsKnownKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []wantProblem
+	}{
+		{
+			"typo", `pipeline:
+  name: orders
+  version: v2.0
+  steps:
+    - name: a
+      type: bash
+      dependson: [b]
+`,
+			[]wantProblem{{"pipeline.steps[0].dependson", 7, 7, `unknown field "dependson", did you mean depends_on?`}},
+		},
+		{
+			"prefixed", `pipeline:
+  name: orders
+  version: v2.0
+  steps:
+    - step_name: a
+      type: bash
+`,
+			[]wantProblem{
+				{"pipeline.steps[0].step_name", 5, 7, `unknown field "step_name", did you mean name?`},
+				{"pipeline.steps[0].name", 5, 7, "name is required"},
+			},
+		},
+		{
+			"nothing close", `pipeline:
+  name: orders
+  version: v2.0
+  retries: 3
+  steps:
+    - name: a
+      type: bash
+`,
+			[]wantProblem{{"pipeline.retries", 4, 3, `unknown field "retries"`}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems, _ := validateProblems(t, tt.content, Options{})
+			checkProblems(t, "problems", problems, tt.want)
+		})
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	known := []string{"depends_on", "description", "name", "table_name", "type"}
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"table", "table_name"},
+		{"step_name", "name"},
+		{"dependson", "depends_on"},
+		{"Type", "type"},
+		{"descripton", "description"},
+		{"nmae", "name"},
+		{"retries", ""},
+		{"x", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := suggest(tt.key, known); got != tt.want {
+				t.Errorf("suggest(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package validation
+
+import (
+	"strings"
+)
+
+// suggest returns the known key closest to an unknown one, or an empty string
+// if none is close enough to be a likely typo.
+func suggest(key string, known []string) string {
+	best, bestDistance := "", 0
+	for _, candidate := range known {
+		// "table" for "table_name", "name" for "step_name"
+		if strings.HasPrefix(candidate, key+"_") || strings.HasSuffix(candidate, "_"+key) ||
+			strings.HasPrefix(key, candidate+"_") || strings.HasSuffix(key, "_"+candidate) {
+			return candidate
+		}
+
+		distance := levenshtein(strings.ToLower(key), candidate)
+		if distance <= max(2, len(candidate)/3) && (best == "" || distance < bestDistance ||
+			(distance == bestDistance && candidate < best)) {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+// levenshtein counts the single character edits needed to turn a into b.
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,413 @@
+// Package validation checks pipeline definitions and reports every problem
+// found, with the YAML line and column it was found at.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/entity"
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/schema"
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/semver"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlErrorLine extracts the line number from yaml.v3 error messages.
+var yamlErrorLine = regexp.MustCompile(`line (\d+): `)
+
+// Problem is a single issue with a pipeline definition.
+type Problem struct {
+	Path    string `json:"path"` // e.g. pipeline.steps[1].depends_on[0]
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	var location []string
+	switch {
+	case p.Line > 0 && p.Column > 0:
+		location = append(location, fmt.Sprintf("%d:%d", p.Line, p.Column))
+	case p.Line > 0:
+		location = append(location, fmt.Sprintf("line %d", p.Line))
+	}
+	if p.Path != "" {
+		location = append(location, p.Path)
+	}
+
+	if len(location) == 0 {
+		return p.Message
+	}
+	return strings.Join(location, " ") + ": " + p.Message
+}
+
+// Errors is every problem found in a pipeline definition.
+type Errors []Problem
+
+func (e Errors) Error() string {
+	lines := make([]string, len(e))
+	for i, problem := range e {
+		lines[i] = problem.String()
+	}
+	return fmt.Sprintf("%d problem(s) in pipeline definition:\n%s", len(e), strings.Join(lines, "\n"))
+}
+
+// sort orders problems as they appear in the document.
+func (e Errors) sort() {
+	slices.SortStableFunc(e, func(a, b Problem) int {
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+		return a.Column - b.Column
+	})
+}
+
+// Options control how strictly pipeline definitions are checked.
+type Options struct {
+	// LenientVersions lists pipeline versions written before unknown keys were
+	// rejected. Unknown keys in them are reported as warnings instead.
+	LenientVersions []string
+	// AllowedImports are the module prefixes config.operator and
+	// config.callable may import from, DefaultAllowedImports when empty.
+	AllowedImports []string
+	// Versions are the pipeline versions that can be rendered, those of the
+	// DAG templates, schema.Versions when empty. A pipeline's version is
+	// resolved against them like a template is, see semver.Resolve.
+	Versions []string
+}
+
+func (o Options) versions() []string {
+	if len(o.Versions) == 0 {
+		return schema.Versions
+	}
+	return o.Versions
+}
+
+// DefaultAllowedImports lets steps name Airflow's own operators and nothing
+// else, arbitrary imports run arbitrary code on the Airflow scheduler.
+var DefaultAllowedImports = []string{"airflow."}
+
+func (o Options) allowedImports() []string {
+	if len(o.AllowedImports) == 0 {
+		return DefaultAllowedImports
+	}
+	return o.AllowedImports
+}
+
+// allowedImport reports whether an import path is in one of the modules of
+// AllowedImports. A prefix matches whole module names, so airflow matches
+// airflow.operators but not airflow_plugins.
+func (o Options) allowedImport(path string) bool {
+	for _, prefix := range o.allowedImports() {
+		module := strings.TrimSuffix(strings.TrimSpace(prefix), ".")
+		if module != "" && strings.HasPrefix(path, module+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// lenient reports whether a pipeline version is one of LenientVersions, which
+// are resolved like the version itself, so v1.0 covers 1.0.0 and 1.x.
+func (o Options) lenient(version string) bool {
+	if slices.Contains(o.LenientVersions, version) {
+		return true
+	}
+	resolved, err := semver.Resolve(version, o.versions())
+	if err != nil {
+		return false
+	}
+	for _, lenient := range o.LenientVersions {
+		if other, err := semver.Resolve(lenient, o.versions()); err == nil && other == resolved {
+			return true
+		}
+	}
+	return false
+}
+
+// Result is a valid pipeline definition and anything its author should still fix.
+type Result struct {
+	Pipeline *entity.UnifiedPipelineDefinition
+	Warnings Errors
+}
+
+// Validate decodes a pipeline definition and checks it. When anything is wrong
+// the returned error is an Errors listing every problem, in document order
+// where possible.
+func Validate(content []byte, opts Options) (*Result, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, Errors{yamlProblem(err)}
+	}
+
+	var upd entity.UnifiedPipelineDefinition
+	if err := root.Decode(&upd); err != nil {
+		return nil, yamlProblems(err)
+	}
+
+	if len(root.Content) == 0 {
+		return nil, Errors{{Message: "pipeline definition is empty"}}
+	}
+	v := &validator{positions: indexPositions(&root), opts: opts}
+
+	// Structure comes from the schema, the rest needs the whole definition.
+	// Without a known version the rest is still checked against the latest
+	s, err := v.versionSchema(upd.Pipeline.Version)
+	if err != nil {
+		if upd.Pipeline.Version != "" {
+			v.report("pipeline.version", "%s", err)
+		}
+		s = schema.Latest()
+	}
+	v.schema = s
+	strict := !opts.lenient(upd.Pipeline.Version)
+
+	v.checkSchema(root.Content[0], s, "", strict)
+	v.pipeline(&upd.Pipeline)
+
+	v.warnings.sort()
+	if len(v.problems) > 0 {
+		v.problems.sort()
+		return nil, v.problems
+	}
+	return &Result{Pipeline: &upd, Warnings: v.warnings}, nil
+}
+
+// validator collects problems while walking a decoded definition.
+type validator struct {
+	schema    *schema.Schema // of the pipeline's version
+	opts      Options
+	positions positions
+	problems  Errors
+	warnings  Errors
+}
+
+// versionSchema resolves a pipeline version the way DAG templates are
+// resolved, and returns the schema of the version it resolves to.
+func (v *validator) versionSchema(version string) (*schema.Schema, error) {
+	resolved, err := semver.Resolve(version, v.opts.versions())
+	if errors.Is(err, semver.ErrNoMatch) {
+		return nil, fmt.Errorf("unknown pipeline version %q, available versions: %s", version, strings.Join(v.opts.versions(), ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return schema.For(resolved)
+}
+
+// report records a problem at path, positioned at the closest node in the document.
+func (v *validator) report(path, format string, args ...any) {
+	line, column := v.positions.lookup(path)
+	v.problems = append(v.problems, Problem{
+		Path:    path,
+		Line:    line,
+		Column:  column,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) pipeline(p *entity.Pipeline) {
+	if p.Schedule != nil {
+		v.schedule("pipeline.schedule", p.Schedule)
+	}
+	if p.Notifications != nil {
+		v.notifications("pipeline.notifications", p.Notifications)
+	}
+	v.steps("pipeline.steps", p)
+}
+
+// steps checks what the schema cannot express: unique step names, depends_on
+// references and cycles.
+func (v *validator) steps(path string, p *entity.Pipeline) {
+	names := map[string]int{}
+	for i, step := range p.Steps {
+		if step.Name == "" {
+			continue
+		}
+		if first, duplicate := names[step.Name]; duplicate {
+			v.report(fmt.Sprintf("%s[%d].name", path, i), "step name %q is already used by %s[%d]", step.Name, path, first)
+			continue
+		}
+		names[step.Name] = i
+	}
+
+	for i, step := range p.Steps {
+		if step.Notifications != nil {
+			v.notifications(fmt.Sprintf("%s[%d].notifications", path, i), step.Notifications)
+		}
+
+		v.stepImports(fmt.Sprintf("%s[%d].config", path, i), step)
+
+		switch {
+		case step.TransformationQueryFile == "":
+		case step.TransformationQuery != "":
+			v.report(fmt.Sprintf("%s[%d].transformation_query_file", path, i), "set either transformation_query or transformation_query_file, not both")
+		case strings.HasPrefix(step.TransformationQueryFile, "/"):
+			v.report(fmt.Sprintf("%s[%d].transformation_query_file", path, i), "%q must be relative to the pipeline definition", step.TransformationQueryFile)
+		}
+	}
+
+	// Cycles can only be reported once every reference resolves
+	dependenciesValid := true
+	for i, step := range p.Steps {
+		for j, dependency := range step.DependsOn {
+			if _, ok := names[dependency]; !ok {
+				v.report(fmt.Sprintf("%s[%d].depends_on[%d]", path, i, j), "depends on unknown step %q", dependency)
+				dependenciesValid = false
+			}
+		}
+	}
+	if !dependenciesValid || len(names) != len(p.Steps) {
+		return
+	}
+
+	if _, err := p.SortedSteps(); err != nil {
+		v.report(path, "%s", err)
+	}
+}
+
+// stepImports checks that the operator or callable a step config imports is
+// in an allowed module. Import paths that are not strings are left to the
+// operator builder, which reports them along with its other config checks.
+func (v *validator) stepImports(path string, step entity.Step) {
+	config, _ := step.Config.(map[string]any)
+	key := "operator"
+	if _, ok := config[key]; !ok && step.Type == "python" {
+		key = "callable"
+	}
+	importPath, ok := config[key].(string)
+	if !ok || v.opts.allowedImport(importPath) {
+		return
+	}
+	v.report(path+"."+key, "%q is not in an allowed module, imports must start with %s", importPath, strings.Join(v.opts.allowedImports(), ", "))
+}
+
+// notifications checks that every target says where to send to, unknown
+// methods are left to the schema.
+func (v *validator) notifications(path string, n *entity.Notifications) {
+	v.notificationTargets(path+".on_success", n.OnSuccess)
+	v.notificationTargets(path+".on_failure", n.OnFailure)
+}
+
+func (v *validator) notificationTargets(path string, targets []entity.NotificationTarget) {
+	for i, target := range targets {
+		switch {
+		case target.Method == "email" && len(target.Recipients) == 0:
+			v.report(fmt.Sprintf("%s[%d].recipients", path, i), "email notifications need recipients")
+		case target.Method == "slack" && target.Channel == "":
+			v.report(fmt.Sprintf("%s[%d].channel", path, i), "slack notifications need a channel")
+		}
+	}
+}
+
+func (v *validator) schedule(path string, schedule *entity.Schedule) {
+	// 1. The expression must be written as the type says
+	scheduleType := ScheduleType(schedule)
+	written := expressionType(schedule.Expression)
+	switch {
+	case scheduleType == "dataset":
+		if schedule.Expression != "" {
+			v.report(path+".expression", "dataset schedules run when their datasets are updated, leave expression out")
+		}
+	case written != "" && written != "cron" && written != scheduleType && slices.Contains(schema.ScheduleTypes, scheduleType):
+		// Anything is read as cron, so only presets and timedeltas are recognisable
+		v.report(path+".expression", "%q is a %s expression, not a %s one", schedule.Expression, written, scheduleType)
+	case schedule.Type == "" && !slices.Contains(v.schema.Property("pipeline", "schedule", "type").Enum, scheduleType):
+		// The schema only sees explicit types
+		v.report(path+".expression", "%q is a %s expression, which needs a later pipeline version, this one supports %s",
+			schedule.Expression, scheduleType, strings.Join(v.schema.Property("pipeline", "schedule", "type").Enum, ", "))
+	case scheduleType == "cron":
+		if err := ValidateCron(schedule.Expression); err != nil {
+			v.report(path+".expression", "%s", err)
+		}
+	case scheduleType == "preset":
+		if !slices.Contains(SchedulePresets, schedule.Expression) {
+			v.report(path+".expression", "unknown preset %q, expected one of %s", schedule.Expression, strings.Join(SchedulePresets, ", "))
+		}
+	case scheduleType == "timedelta":
+		if _, err := ParseTimedelta(schedule.Expression); err != nil {
+			v.report(path+".expression", "%s", err)
+		}
+	}
+
+	// 2. Only dataset schedules are triggered by datasets
+	if scheduleType == "dataset" {
+		if len(schedule.Datasets) == 0 {
+			v.report(path+".datasets", "dataset schedules need at least one dataset URI")
+		}
+		for i, uri := range schedule.Datasets {
+			if strings.TrimSpace(uri) == "" {
+				v.report(fmt.Sprintf("%s.datasets[%d]", path, i), "dataset URI is empty")
+			}
+		}
+	} else if len(schedule.Datasets) > 0 {
+		v.report(path+".datasets", "datasets only trigger dataset schedules, %s schedules ignore them", scheduleType)
+	}
+
+	// 3. Dates are in the schedule's time zone
+	if schedule.Timezone != "" {
+		if err := ValidateTimezone(schedule.Timezone); err != nil {
+			v.report(path+".timezone", "%s", err)
+		}
+	}
+	var start, end time.Time
+	if schedule.StartDate != "" {
+		var err error
+		if start, err = ParseScheduleDate(schedule.StartDate); err != nil {
+			v.report(path+".start_date", "%s", err)
+		}
+	}
+	if schedule.EndDate != "" {
+		var err error
+		if end, err = ParseScheduleDate(schedule.EndDate); err != nil {
+			v.report(path+".end_date", "%s", err)
+		}
+	}
+	if !start.IsZero() && !end.IsZero() && !end.After(start) {
+		v.report(path+".end_date", "end_date %s is not after start_date %s", schedule.EndDate, schedule.StartDate)
+	}
+
+	// 4. Concurrency
+	switch {
+	case schedule.MaxActiveRuns < 0:
+		v.report(path+".max_active_runs", "max_active_runs must be at least 1")
+	case schedule.Expression == "@continuous" && schedule.MaxActiveRuns > 1:
+		v.report(path+".max_active_runs", "@continuous schedules can only have 1 active run")
+	}
+}
+
+// yamlProblem converts a YAML syntax error into a problem.
+func yamlProblem(err error) Problem {
+	message := strings.TrimPrefix(err.Error(), "yaml: ")
+	return problemFromYAMLMessage(message)
+}
+
+// yamlProblems converts a decoding error, which can list several fields of the
+// wrong type, into problems.
+func yamlProblems(err error) Errors {
+	typeErr, ok := err.(*yaml.TypeError)
+	if !ok {
+		return Errors{yamlProblem(err)}
+	}
+
+	problems := make(Errors, 0, len(typeErr.Errors))
+	for _, message := range typeErr.Errors {
+		problems = append(problems, problemFromYAMLMessage(message))
+	}
+	return problems
+}
+
+func problemFromYAMLMessage(message string) Problem {
+	problem := Problem{Message: message}
+	if match := yamlErrorLine.FindStringSubmatchIndex(message); match != nil {
+		problem.Line, _ = strconv.Atoi(message[match[2]:match[3]])
+		problem.Message = message[:match[0]] + message[match[1]:]
+	}
+	return problem
+}
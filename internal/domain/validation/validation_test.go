@@ -0,0 +1,243 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// wantProblem is a problem expected at a position, with a message containing message.
+type wantProblem struct {
+	path         string
+	line, column int
+	message      string
+}
+
+// checkProblems compares problems in order, only requiring messages to
+// contain the expected text.
+func checkProblems(t *testing.T, kind string, got Errors, want []wantProblem) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d %s, want %d:\n%s", len(got), kind, len(want), got.Error())
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.Path != w.path || g.Line != w.line || g.Column != w.column || !strings.Contains(g.Message, w.message) {
+			t.Errorf("%s[%d] = %s, want %d:%d %s: ...%s...", kind, i, g, w.line, w.column, w.path, w.message)
+		}
+	}
+}
+
+// validateProblems returns the problems Validate finds in a definition, none
+// when it is valid.
+func validateProblems(t *testing.T, content string, opts Options) (problems, warnings Errors) {
+	t.Helper()
+	result, err := Validate([]byte(content), opts)
+	if err == nil {
+		return nil, result.Warnings
+	}
+	if !errors.As(err, &problems) {
+		t.Fatalf("Validate() = %v, want Errors", err)
+	}
+	return problems, nil
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []wantProblem
+	}{
+		{
+			name: "valid",
+			content: `pipeline:
+  name: orders
+  version: v2.0
+  steps:
+    - name: extract
+      type: bash
+    - name: load
+      type: bash
+      depends_on: [extract]
+`,
+		},
+		{
+			name: "every problem in document order",
+			content: `pipeline:
+  name: "daily orders"
+  version: v2.0
+  steps:
+    - name: extract
+      type: shell
+    - name: extract
+      type: bash
+      depends_on: [transform]
+`,
+			want: []wantProblem{
+				{"pipeline.name", 2, 9, `name "daily orders" may only contain letters`},
+				{"pipeline.steps[0].type", 6, 13, `unknown type "shell", expected one of ingestion`},
+				{"pipeline.steps[1].name", 7, 13, `step name "extract" is already used by pipeline.steps[0]`},
+				{"pipeline.steps[1].depends_on[0]", 9, 20, `depends on unknown step "transform"`},
+			},
+		},
+		{
+			name: "required fields",
+			content: `pipeline:
+  version: v2.0
+  steps: []
+`,
+			want: []wantProblem{
+				{"pipeline.name", 2, 3, "name is required"},
+				{"pipeline.steps", 3, 10, "steps must not be empty"},
+			},
+		},
+		{
+			name: "required field of a step",
+			content: `pipeline:
+  name: orders
+  version: v2.0
+  steps:
+    - name: extract
+      type: bash
+      outputs:
+        - name: raw
+`,
+			want: []wantProblem{
+				{"pipeline.steps[0].outputs[0].type", 8, 11, "type is required"},
+			},
+		},
+		{
+			name: "dependency cycle",
+			content: `pipeline:
+  name: orders
+  version: v2.0
+  steps:
+    - name: a
+      type: bash
+      depends_on: [c]
+    - name: b
+      type: bash
+      depends_on: [a]
+    - name: c
+      type: bash
+      depends_on: [b]
+`,
+			want: []wantProblem{
+				{"pipeline.steps", 5, 5, "dependency cycle: a -> c -> b -> a"},
+			},
+		},
+		{
+			name: "cycles are not reported with unknown dependencies",
+			content: `pipeline:
+  name: orders
+  version: v2.0
+  steps:
+    - name: a
+      type: bash
+      depends_on: [b, missing]
+    - name: b
+      type: bash
+      depends_on: [a]
+`,
+			want: []wantProblem{
+				{"pipeline.steps[0].depends_on[1]", 7, 23, `depends on unknown step "missing"`},
+			},
+		},
+		{
+			name: "wrong type",
+			content: `pipeline:
+  name: orders
+  version: v2.0
+  steps:
+    - name: a
+      type: [bash]
+`,
+			want: []wantProblem{
+				{"", 6, 0, "cannot unmarshal !!seq into string"},
+			},
+		},
+		{
+			name: "syntax error",
+			content: `pipeline:
+  name: "orders
+  version: v2.0
+`,
+			want: []wantProblem{
+				{"", 2, 0, "found unexpected end of stream"},
+			},
+		},
+		{
+			name: "unknown version",
+			content: `pipeline:
+  name: orders
+  version: "1.5"
+  steps:
+    - name: a
+      type: bash
+`,
+			want: []wantProblem{
+				{"pipeline.version", 3, 12, `unknown pipeline version "1.5", available versions: v1.0, v2.0`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems, _ := validateProblems(t, tt.content, Options{})
+			checkProblems(t, "problems", problems, tt.want)
+		})
+	}
+}
+
+func TestValidateSchedules(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule string
+		want     []wantProblem
+	}{
+		{"cron", `{expression: "*/15 9-17 * JAN-MAR MON-FRI"}`, nil},
+		{"preset", `{expression: "@daily"}`, nil},
+		{"timedelta", `{expression: 1d12h}`, nil},
+		{"dataset", `{datasets: ["s3://lake/orders"]}`, nil},
+		{
+			"cron out of range", `{expression: "0 25 * * *"}`,
+			[]wantProblem{{"pipeline.schedule.expression", 5, 26, "value 25 in hour field is out of range 0-23"}},
+		},
+		{
+			"cron with too few fields", `{expression: "0 6 * *"}`,
+			[]wantProblem{{"pipeline.schedule.expression", 5, 26, "has 4 fields, expected 5"}},
+		},
+		{
+			"unknown preset", `{expression: "@fortnightly"}`,
+			[]wantProblem{{"pipeline.schedule.expression", 5, 26, `unknown preset "@fortnightly"`}},
+		},
+		{
+			"expression of another type", `{type: preset, expression: 30m}`,
+			[]wantProblem{{"pipeline.schedule.expression", 5, 40, `"30m" is a timedelta expression, not a preset one`}},
+		},
+		{
+			"dataset schedule with an expression", `{type: dataset, expression: "@daily", datasets: ["s3://lake/orders"]}`,
+			[]wantProblem{{"pipeline.schedule.expression", 5, 41, "leave expression out"}},
+		},
+		{
+			"end before start", `{expression: "@daily", start_date: 2024-06-01, end_date: 2024-01-01}`,
+			[]wantProblem{{"pipeline.schedule.end_date", 5, 70, "is not after start_date"}},
+		},
+		{
+			"unknown time zone", `{expression: "@daily", timezone: Mars/Olympus}`,
+			[]wantProblem{{"pipeline.schedule.timezone", 5, 46, `unknown time zone "Mars/Olympus"`}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := `pipeline:
+  name: orders
+  version: v2.0
+  steps: [{name: a, type: bash}]
+  schedule: ` + tt.schedule + "\n"
+			problems, _ := validateProblems(t, content, Options{})
+			checkProblems(t, "problems", problems, tt.want)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package validation
+
+import "testing"
+
+func TestValidateVersionRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		extra   string // appended to the pipeline
+		want    []wantProblem
+	}{
+		{
+			name: "notifications in v1.0", version: "v1.0",
+			extra: "  notifications: {on_failure: [{method: email, recipients: [a@example.com]}]}\n",
+			want: []wantProblem{
+				{"pipeline.notifications", 5, 3, `unknown field "notifications", it needs pipeline version v2.0 or later`},
+			},
+		},
+		{
+			name: "notifications in v2.0", version: "v2.0",
+			extra: "  notifications: {on_failure: [{method: email, recipients: [a@example.com]}]}\n",
+		},
+		{
+			name: "schedule timezone in v1.0", version: "1.0.0",
+			extra: "  schedule: {expression: \"@daily\", timezone: Europe/Amsterdam}\n",
+			want: []wantProblem{
+				{"pipeline.schedule.timezone", 5, 36, `unknown field "timezone", it needs pipeline version v2.0 or later`},
+			},
+		},
+		{
+			name: "schedule timezone in v2.0", version: "2.x",
+			extra: "  schedule: {expression: \"@daily\", timezone: Europe/Amsterdam}\n",
+		},
+		{
+			name: "timedelta type in v1.0", version: "v1.0",
+			extra: "  schedule: {type: timedelta, expression: 30m}\n",
+			want: []wantProblem{
+				{"pipeline.schedule.type", 5, 20, `unknown type "timedelta", expected one of cron, preset`},
+			},
+		},
+		{
+			name: "inferred timedelta in v1.0", version: "v1.0",
+			extra: "  schedule: {expression: 30m}\n",
+			want: []wantProblem{
+				{"pipeline.schedule.expression", 5, 26, `"30m" is a timedelta expression, which needs a later pipeline version, this one supports cron, preset`},
+			},
+		},
+		{
+			name: "timedelta in v2.0", version: "v2.0",
+			extra: "  schedule: {expression: 30m}\n",
+		},
+		{
+			name: "unknown version checked against the latest schema", version: "9.0",
+			extra: "  notifications: {on_failure: [{method: slack}]}\n",
+			want: []wantProblem{
+				{"pipeline.version", 3, 12, `unknown pipeline version "9.0"`},
+				{"pipeline.notifications.on_failure[0].channel", 5, 32, "slack notifications need a channel"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := `pipeline:
+  name: orders
+  version: "` + tt.version + `"
+  steps: [{name: a, type: bash}]
+` + tt.extra
+			problems, _ := validateProblems(t, content, Options{})
+			checkProblems(t, "problems", problems, tt.want)
+		})
+	}
+}
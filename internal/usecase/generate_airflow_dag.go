@@ -5,38 +5,101 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"os"
+	"path"
+	"path/filepath"
+	"regexp"
 	"strings"
-	"text/template"
 
+	"github.com/Suhaibshah22/pipeweaver/cmd/config"
 	"github.com/Suhaibshah22/pipeweaver/internal/domain/entity"
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/port/validator"
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/validation"
+	"github.com/Suhaibshah22/pipeweaver/internal/usecase/templates"
 
-	"gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
 )
 
-const TEMPLATE_BASE_PATH = "internal/usecase/templates/dag_template.py.tmpl"
-
 type GenerateAirFlowDAGUsecase interface {
-	Execute(ctx context.Context, pipelineFileContent []byte, filePath string) ([]byte, error)
+	Execute(ctx context.Context, pipelineFileContent []byte, filePath string, readFile FileReader) (*GeneratedDAG, error)
 }
 
+// FileReader reads a file of the pipelines repository by its path from the
+// repository root, such as the SQL file of a step.
+type FileReader func(ctx context.Context, path string) ([]byte, error)
+
 type generateAirFlowDAGUsecase struct {
-	Log *slog.Logger
+	Templates     *templates.Registry
+	DAGValidators []validator.DAGValidator
+	Config        *config.Config
+	Log           *slog.Logger
 }
 
 func NewGenerateAirFlowDAGUsecase(
+	registry *templates.Registry,
+	dagValidators []validator.DAGValidator,
 	logger *slog.Logger,
+	cfg *config.Config,
 ) GenerateAirFlowDAGUsecase {
 	return &generateAirFlowDAGUsecase{
-		Log: logger,
+		Templates:     registry,
+		DAGValidators: dagValidators,
+		Config:        cfg,
+		Log:           logger,
+	}
+}
+
+// GeneratedDAG is a rendered DAG along with anything the pipeline author should
+// know about, such as ignored fields.
+type GeneratedDAG struct {
+	Content []byte
+
+	// DefinitionWarnings are those the pipeline definition was validated
+	// with, Warnings those of generating the DAG, such as a deprecated template
+	DefinitionWarnings validation.Errors
+	Warnings           []string
+
+	// SQLFiles are the queries of steps with a transformation_query_file,
+	// written to SQLDirFor the pipeline and read by the DAG from there
+	SQLFiles []entity.File
+}
+
+// AllWarnings returns the warnings of the definition followed by those of
+// generating the DAG.
+func (d *GeneratedDAG) AllWarnings() []string {
+	warnings := make([]string, 0, len(d.DefinitionWarnings)+len(d.Warnings))
+	for _, warning := range d.DefinitionWarnings {
+		warnings = append(warnings, warning.String())
 	}
+	return append(warnings, d.Warnings...)
 }
 
+// DAGTemplateData is what DAG templates are executed with. Definition is the
+// whole pipeline definition, the other fields are derived from it.
 type DAGTemplateData struct {
+	Definition *entity.UnifiedPipelineDefinition
+	Pipeline   *entity.Pipeline
+
 	PipelineName        string
 	PipelineDescription string
-	ScheduleInterval    string
-	TaskName            string
+
+	// Owner names the owners for the Airflow UI, "airflow" when there are
+	// none. OwnerEmails are the owners that have an email address
+	Owner       string
+	OwnerEmails []string
+	Tags        []string // domain:<domain> and version:<version>
+	DocMD       string   // Markdown shown on the DAG page, empty without descriptions
+
+	// SQLDirectory holds the SQL files of the steps, relative to the DAG. It
+	// is empty when no step has one
+	SQLDirectory string
+
+	// Tasks has one Airflow task per step, dependencies first
+	Tasks        []DAGTask
+	Dependencies []DAGDependency
+
+	// Kept for templates written before the whole definition was available.
+	// TaskName is the first step, for templates that render a single task
+	TaskName string
 
 	// Postgres
 	PostgresHost     string
@@ -47,19 +110,75 @@ type DAGTemplateData struct {
 	SnowflakeTable string
 }
 
-func (uc *generateAirFlowDAGUsecase) Execute(ctx context.Context, pipelineFileContent []byte, filePath string) ([]byte, error) {
-	// 1. Parse the pipeline YAML
-	upd, err := parseUPD(pipelineFileContent)
+// DAGTask is the Airflow task generated for one pipeline step.
+type DAGTask struct {
+	Step entity.Step
+
+	TaskID      string
+	Variable    templates.Python // Python variable holding the operator
+	Callable    templates.Python // Python function run by the operator
+	Type        string
+	Description string
+	Inputs      []string
+	Outputs     []string
+}
+
+// DAGDependency wires an upstream task into a downstream task (upstream >> downstream).
+type DAGDependency struct {
+	Upstream   templates.Python
+	Downstream templates.Python
+}
+
+func (uc *generateAirFlowDAGUsecase) Execute(ctx context.Context, pipelineFileContent []byte, filePath string, readFile FileReader) (*GeneratedDAG, error) {
+	// 1. Parse and validate the pipeline YAML, against the versions there are templates for
+	versions, err := uc.Templates.Versions()
+	if err != nil {
+		uc.Log.Error("Failed to list DAG templates", "error", err)
+		return nil, err
+	}
+	validated, err := validation.Validate(pipelineFileContent, validation.Options{
+		LenientVersions: uc.Config.Pipeline.LenientVersions,
+		AllowedImports:  uc.Config.Pipeline.AllowedImports,
+		Versions:        versions,
+	})
 	if err != nil {
-		uc.Log.Error("ParseUPD error", "error", err)
-		return nil, fmt.Errorf("ParseUPD error: %w", err)
+		uc.Log.Error("Invalid pipeline definition", "filePath", filePath, "error", err)
+		return nil, fmt.Errorf("invalid pipeline definition %s: %w", filePath, err)
+	}
+	upd := validated.Pipeline
+
+	for _, warning := range validated.Warnings {
+		uc.Log.Warn("Pipeline definition warning", "filePath", filePath, "warning", warning.String())
 	}
 
-	// 2. Prepare DAG template data
+	// 2. Order the steps by their dependencies
+	steps, err := upd.Pipeline.SortedSteps()
+	if err != nil {
+		uc.Log.Error("Invalid step dependencies", "filePath", filePath, "error", err)
+		return nil, fmt.Errorf("invalid step dependencies: %w", err)
+	}
+	tasks, dependencies := buildTasks(steps)
+
+	// 3. Read the SQL files of the steps, they are copied next to the DAG
+	sqlFiles, err := readSQLFiles(ctx, readFile, filePath, steps)
+	if err != nil {
+		uc.Log.Error("Unable to read SQL file", "filePath", filePath, "error", err)
+		return nil, err
+	}
+
+	// 4. Prepare DAG template data
 	dagData := DAGTemplateData{
+		Definition: upd,
+		Pipeline:   &upd.Pipeline,
+
 		PipelineName:        upd.Pipeline.Name,
 		PipelineDescription: upd.Pipeline.Description,
-		ScheduleInterval:    getScheduleInterval(upd.Pipeline.Schedule),
+		Owner:               ownerNames(upd.Pipeline.Owners),
+		OwnerEmails:         ownerEmails(upd.Pipeline.Owners),
+		Tags:                dagTags(&upd.Pipeline),
+		DocMD:               docMarkdown(&upd.Pipeline, steps),
+		Tasks:               tasks,
+		Dependencies:        dependencies,
 		TaskName:            generateTaskName(upd.Pipeline.Steps),
 
 		PostgresHost:     getDataRef(upd.Pipeline.Steps, "Postgres").Host,
@@ -68,13 +187,88 @@ func (uc *generateAirFlowDAGUsecase) Execute(ctx context.Context, pipelineFileCo
 
 		SnowflakeTable: getDataRef(upd.Pipeline.Steps, "Snowflake").TableName,
 	}
+	if len(sqlFiles) > 0 {
+		sqlDirectory, err := filepath.Rel(filepath.Dir(DAGPathFor(filePath)), SQLDirFor(filePath))
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate SQL files: %w", err)
+		}
+		dagData.SQLDirectory = filepath.ToSlash(sqlDirectory)
+	}
+
+	// 5. Resolve the template for the pipeline version
+	dagTemplate, err := uc.Templates.Resolve(upd.Pipeline.Version)
+	if err != nil {
+		uc.Log.Error("Unable to resolve DAG template", "filePath", filePath, "version", upd.Pipeline.Version, "error", err)
+		return nil, fmt.Errorf("pipeline.version: %w", err)
+	}
+	uc.Log.Info("Pipeline template resolved", "version", upd.Pipeline.Version, "template", dagTemplate.Name)
+
+	var warnings []string
+	if dagTemplate.Deprecated != "" {
+		warning := fmt.Sprintf("pipeline.version: %q uses DAG template %s, which is deprecated: %s", upd.Pipeline.Version, dagTemplate.Version, dagTemplate.Deprecated)
+		uc.Log.Warn("Pipeline uses a deprecated DAG template", "filePath", filePath, "template", dagTemplate.Name)
+		warnings = append(warnings, warning)
+	}
+
+	// 6. Generate DAG content
+	content, err := GenerateAirflowDAG(uc, dagData, dagTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	// 7. Verify the DAG before anything commits it
+	dagPath := DAGPathFor(filePath)
+	for _, dagValidator := range uc.DAGValidators {
+		if err := dagValidator.Validate(ctx, dagPath, content); err != nil {
+			uc.Log.Error("Generated DAG failed validation", "filePath", filePath, "dagPath", dagPath, "template", dagTemplate.Name, "error", err)
+			return nil, fmt.Errorf("generated DAG failed validation: %w", err)
+		}
+	}
+
+	return &GeneratedDAG{Content: content, DefinitionWarnings: validated.Warnings, Warnings: warnings, SQLFiles: sqlFiles}, nil
+}
+
+// readSQLFiles reads the transformation_query_file of every step, returning
+// the files to write to the SQL directory of the pipeline.
+func readSQLFiles(ctx context.Context, readFile FileReader, filePath string, steps []entity.Step) ([]entity.File, error) {
+	var files []entity.File
+	for _, step := range steps {
+		if step.TransformationQueryFile == "" {
+			continue
+		}
+
+		queryPath, err := queryFilePath(filePath, step)
+		if err != nil {
+			return nil, err
+		}
+		content, err := readFile(ctx, queryPath)
+		if err != nil {
+			return nil, fmt.Errorf("step %q: failed to read transformation_query_file %s: %w", step.Name, queryPath, err)
+		}
+		files = append(files, entity.File{
+			Path:    path.Join(SQLDirFor(filePath), sqlFileName(step)),
+			Content: content,
+		})
+	}
+	return files, nil
+}
 
-	// 3. Determine Template Path based on version
-	templatePath := TEMPLATE_BASE_PATH + "." + upd.Pipeline.Version
-	uc.Log.Info("Pipeline template path", "info", templatePath)
+// queryFilePath resolves the transformation_query_file of a step, relative to
+// the pipeline definition, to a path from the repository root.
+func queryFilePath(pipelinePath string, step entity.Step) (string, error) {
+	resolved := path.Join(path.Dir(pipelinePath), step.TransformationQueryFile)
+	if path.IsAbs(step.TransformationQueryFile) || resolved == ".." || strings.HasPrefix(resolved, "../") {
+		return "", fmt.Errorf("step %q: transformation_query_file %s is outside of the repository", step.Name, step.TransformationQueryFile)
+	}
+	return resolved, nil
+}
 
-	// 4. Generate DAG content
-	return GenerateAirflowDAG(uc, dagData, templatePath)
+// SQLDirFor maps a pipeline file to the directory of the SQL files copied
+// for its steps, e.g. airflow-dags/sql/team/orders.
+func SQLDirFor(pipelinePath string) string {
+	relativePath := strings.TrimPrefix(pipelinePath, PIPELINES_DIRECTORY)
+	sqlDir := filepath.Join(OUTPUT_DIRECTORY, "sql", relativePath)
+	return strings.TrimSuffix(sqlDir, filepath.Ext(sqlDir))
 }
 
 func parseUPD(yamlData []byte) (*entity.UnifiedPipelineDefinition, error) {
@@ -86,11 +280,77 @@ func parseUPD(yamlData []byte) (*entity.UnifiedPipelineDefinition, error) {
 	return &upd, nil
 }
 
-func getScheduleInterval(schedule *entity.Schedule) string {
-	if schedule == nil || schedule.Expression == "" {
-		return "None"
+// ownerNames joins the owners' names the way Airflow lists several owners.
+func ownerNames(owners []entity.Owner) string {
+	names := make([]string, 0, len(owners))
+	for _, owner := range owners {
+		if owner.Name != "" {
+			names = append(names, owner.Name)
+		}
+	}
+	if len(names) == 0 {
+		return "airflow"
+	}
+	return strings.Join(names, ",")
+}
+
+func ownerEmails(owners []entity.Owner) []string {
+	var emails []string
+	for _, owner := range owners {
+		if owner.Email != "" {
+			emails = append(emails, owner.Email)
+		}
+	}
+	return emails
+}
+
+// dagTags makes the pipeline's domain and version searchable in the Airflow UI.
+func dagTags(p *entity.Pipeline) []string {
+	var tags []string
+	if p.Domain != "" {
+		tags = append(tags, "domain:"+p.Domain)
+	}
+	if p.Version != "" {
+		tags = append(tags, "version:"+p.Version)
+	}
+	return tags
+}
+
+// docMarkdown documents the pipeline and its steps for the DAG page, it is
+// empty when neither has a description.
+func docMarkdown(p *entity.Pipeline, steps []entity.Step) string {
+	var described []entity.Step
+	for _, step := range steps {
+		if step.Description != "" {
+			described = append(described, step)
+		}
+	}
+	if p.Description == "" && len(described) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", p.Name)
+	if p.Description != "" {
+		fmt.Fprintf(&b, "\n%s\n", p.Description)
 	}
-	return fmt.Sprintf(`"%s"`, schedule.Expression)
+	if len(p.Owners) > 0 {
+		owners := make([]string, len(p.Owners))
+		for i, owner := range p.Owners {
+			owners[i] = owner.Name
+			if owner.Email != "" {
+				owners[i] += " <" + owner.Email + ">"
+			}
+		}
+		fmt.Fprintf(&b, "\n**Owners:** %s\n", strings.Join(owners, ", "))
+	}
+	if len(described) > 0 {
+		b.WriteString("\n## Steps\n\n")
+		for _, step := range described {
+			fmt.Fprintf(&b, "- **%s** (%s): %s\n", step.Name, step.Type, step.Description)
+		}
+	}
+	return b.String()
 }
 
 func generateTaskName(steps []entity.Step) string {
@@ -100,6 +360,80 @@ func generateTaskName(steps []entity.Step) string {
 	return steps[0].Name
 }
 
+// nonIdentifierChars matches the characters that cannot appear in a Python identifier.
+var nonIdentifierChars = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// buildTasks maps the sorted steps to Airflow tasks and the dependencies between them.
+func buildTasks(steps []entity.Step) ([]DAGTask, []DAGDependency) {
+	tasks := make([]DAGTask, 0, len(steps))
+	variables := make(map[string]templates.Python, len(steps))
+	used := map[string]bool{}
+
+	for _, step := range steps {
+		// Step names become task IDs as is, but need sanitizing to name Python variables
+		base := strings.ToLower(strings.Trim(nonIdentifierChars.ReplaceAllString(step.Name, "_"), "_"))
+		if base == "" || (base[0] >= '0' && base[0] <= '9') {
+			base = "step_" + base
+		}
+		name := base
+		for i := 2; used[name]; i++ {
+			name = fmt.Sprintf("%s_%d", base, i)
+		}
+		used[name] = true
+		variables[step.Name] = templates.Python(name + "_task")
+
+		task := DAGTask{
+			Step:        step,
+			TaskID:      step.Name,
+			Variable:    templates.Python(name + "_task"),
+			Callable:    templates.Python("run_" + name),
+			Type:        step.Type,
+			Description: step.Description,
+		}
+		for _, input := range step.Inputs {
+			task.Inputs = append(task.Inputs, describeDataRef(input))
+		}
+		for _, output := range step.Outputs {
+			task.Outputs = append(task.Outputs, describeDataRef(output))
+		}
+		tasks = append(tasks, task)
+	}
+
+	var dependencies []DAGDependency
+	for _, step := range steps {
+		for _, upstream := range step.DependsOn {
+			dependencies = append(dependencies, DAGDependency{
+				Upstream:   variables[upstream],
+				Downstream: variables[step.Name],
+			})
+		}
+	}
+
+	return tasks, dependencies
+}
+
+// describeDataRef summarises where a step reads or writes data, e.g.
+// "postgres source (host=db, database=app, table=users)".
+func describeDataRef(ref entity.DataRef) string {
+	var details []string
+	for _, detail := range []struct{ key, value string }{
+		{"host", ref.Host},
+		{"database", ref.Database},
+		{"table", ref.TableName},
+		{"path", ref.Path},
+	} {
+		if detail.value != "" {
+			details = append(details, detail.key+"="+detail.value)
+		}
+	}
+
+	description := strings.TrimSpace(ref.Type + " " + ref.Name)
+	if len(details) > 0 {
+		description += " (" + strings.Join(details, ", ") + ")"
+	}
+	return description
+}
+
 func getDataRef(steps []entity.Step, dataType string) entity.DataRef {
 	for _, step := range steps {
 		for _, input := range step.Inputs {
@@ -116,20 +450,14 @@ func getDataRef(steps []entity.Step, dataType string) entity.DataRef {
 	return entity.DataRef{}
 }
 
-func GenerateAirflowDAG(uc *generateAirFlowDAGUsecase, data DAGTemplateData, templatePath string) ([]byte, error) {
-	// 1. Read the template file
-	tmplBytes, err := os.ReadFile(templatePath)
-	if err != nil {
-		return nil, fmt.Errorf("read template error: %w", err)
-	}
-
-	// 2. Parse the template
-	tmpl, err := template.New("dag").Parse(string(tmplBytes))
+func GenerateAirflowDAG(uc *generateAirFlowDAGUsecase, data DAGTemplateData, dagTemplate *templates.Template) ([]byte, error) {
+	// 1. Parse the template, escaping everything it renders
+	tmpl, err := templates.Parse(dagTemplate)
 	if err != nil {
-		return nil, fmt.Errorf("parse template error: %w", err)
+		return nil, err
 	}
 
-	// 3. Execute the template
+	// 2. Execute the template
 	var rendered bytes.Buffer
 	if err := tmpl.Execute(&rendered, data); err != nil {
 		return nil, fmt.Errorf("execute template error: %w", err)
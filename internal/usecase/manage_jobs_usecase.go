@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/entity"
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/port/queue"
+)
+
+type ManageJobsUsecase interface {
+	ListJobs(ctx context.Context, status entity.JobStatus, limit int) ([]*entity.Job, error)
+	FindJob(ctx context.Context, id string) (*entity.Job, error)
+	ListDeadLetters(ctx context.Context) ([]*entity.Job, error)
+	Redrive(ctx context.Context, id string) error
+}
+
+type manageJobsUsecase struct {
+	JobQueue queue.JobQueue
+	Log      *slog.Logger
+}
+
+func NewManageJobsUsecase(
+	jobQueue queue.JobQueue,
+	logger *slog.Logger,
+) ManageJobsUsecase {
+	return &manageJobsUsecase{
+		JobQueue: jobQueue,
+		Log:      logger,
+	}
+}
+
+// ListJobs returns the most recent jobs first, optionally only those with the given status.
+func (uc *manageJobsUsecase) ListJobs(ctx context.Context, status entity.JobStatus, limit int) ([]*entity.Job, error) {
+	return uc.JobQueue.List(ctx, status, limit)
+}
+
+func (uc *manageJobsUsecase) FindJob(ctx context.Context, id string) (*entity.Job, error) {
+	return uc.JobQueue.Find(ctx, id)
+}
+
+// ListDeadLetters returns the failed jobs waiting to be redriven.
+func (uc *manageJobsUsecase) ListDeadLetters(ctx context.Context) ([]*entity.Job, error) {
+	return uc.JobQueue.List(ctx, entity.JobFailed, 0)
+}
+
+// Redrive puts a failed job back on the queue with a fresh set of attempts.
+func (uc *manageJobsUsecase) Redrive(ctx context.Context, id string) error {
+	if err := uc.JobQueue.Redrive(ctx, id); err != nil {
+		return err
+	}
+
+	uc.Log.Info("Dead-lettered job redriven", "job", id)
+	return nil
+}
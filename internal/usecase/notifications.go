@@ -0,0 +1,151 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/entity"
+	"github.com/Suhaibshah22/pipeweaver/internal/usecase/templates"
+)
+
+// notification is what a callback reports, as Jinja templates Airflow renders
+// with the context of the finished task or DAG run.
+type notification struct {
+	subject string
+	message string
+}
+
+// Task callbacks get the task instance, DAG callbacks only the run.
+var (
+	taskNotifications = map[string]notification{
+		"on_success": {
+			subject: "Task {{ ti.task_id }} of {{ dag.dag_id }} succeeded",
+			message: "Task {{ ti.task_id }} of DAG {{ dag.dag_id }} succeeded in run {{ run_id }}. Logs: {{ ti.log_url }}",
+		},
+		"on_failure": {
+			subject: "Task {{ ti.task_id }} of {{ dag.dag_id }} failed",
+			message: "Task {{ ti.task_id }} of DAG {{ dag.dag_id }} failed in run {{ run_id }}. Logs: {{ ti.log_url }}",
+		},
+	}
+	dagNotifications = map[string]notification{
+		"on_success": {
+			subject: "DAG {{ dag.dag_id }} succeeded",
+			message: "DAG {{ dag.dag_id }} succeeded in run {{ run_id }}.",
+		},
+		"on_failure": {
+			subject: "DAG {{ dag.dag_id }} failed",
+			message: "DAG {{ dag.dag_id }} failed in run {{ run_id }}.",
+		},
+	}
+)
+
+// notifierBuilder maps a notification target to the Airflow notifier sending to it.
+type notifierBuilder func(target entity.NotificationTarget, n notification) operatorCall
+
+// notifiers maps notification methods to the Airflow notifiers sending them.
+var notifiers = map[string]notifierBuilder{
+	"email":         emailNotifier,
+	"slack":         slackNotifier,
+	"slack_webhook": slackWebhookNotifier,
+}
+
+// Callbacks returns the DAG's on_success_callback and on_failure_callback
+// arguments, notifying the pipeline's targets when a run finishes.
+func (d DAGTemplateData) Callbacks() ([]OperatorArgument, error) {
+	args, _, err := notificationCallbacks(d.Pipeline.Notifications, dagNotifications)
+	if err != nil {
+		return nil, fmt.Errorf("pipeline notifications: %w", err)
+	}
+	return renderArguments(args)
+}
+
+// notificationCallbacks returns the callback arguments notifying the targets
+// of n, and the import paths of the notifiers they call.
+func notificationCallbacks(n *entity.Notifications, notifications map[string]notification) ([]kwarg, []string, error) {
+	if n == nil {
+		return nil, nil, nil
+	}
+
+	var args []kwarg
+	var imports []string
+	for _, event := range []struct {
+		name    string
+		targets []entity.NotificationTarget
+	}{{"on_success", n.OnSuccess}, {"on_failure", n.OnFailure}} {
+		if len(event.targets) == 0 {
+			continue
+		}
+		calls := make([]templates.Python, len(event.targets))
+		for i, target := range event.targets {
+			build, ok := notifiers[target.Method]
+			if !ok {
+				return nil, nil, fmt.Errorf("%s[%d]: unknown notification method %q", event.name, i, target.Method)
+			}
+			call := build(target, notifications[event.name])
+			expression, err := renderCall(call)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s[%d]: %w", event.name, i, err)
+			}
+			calls[i] = expression
+			imports = append(imports, call.class)
+		}
+		args = append(args, kwarg{event.name + "_callback", calls})
+	}
+	return args, imports, nil
+}
+
+// renderCall renders a call of a notifier function as a Python expression.
+func renderCall(call operatorCall) (templates.Python, error) {
+	_, name := splitImportPath(call.class)
+	args, err := renderArguments(call.args)
+	if err != nil {
+		return "", err
+	}
+	rendered := make([]string, len(args))
+	for i, arg := range args {
+		rendered[i] = string(arg.Name) + "=" + string(arg.Value)
+	}
+	return templates.Python(name + "(" + strings.Join(rendered, ", ") + ")"), nil
+}
+
+// connIDOr returns the target's Airflow connection, or fallback when it has none.
+func connIDOr(target entity.NotificationTarget, fallback string) string {
+	if target.ConnID != "" {
+		return target.ConnID
+	}
+	return fallback
+}
+
+func emailNotifier(target entity.NotificationTarget, n notification) operatorCall {
+	return operatorCall{
+		class: "airflow.providers.smtp.notifications.smtp.send_smtp_notification",
+		args: []kwarg{
+			{"to", target.Recipients},
+			{"subject", n.subject},
+			{"html_content", n.message},
+			{"smtp_conn_id", connIDOr(target, "smtp_default")},
+		},
+	}
+}
+
+func slackNotifier(target entity.NotificationTarget, n notification) operatorCall {
+	return operatorCall{
+		class: "airflow.providers.slack.notifications.slack.send_slack_notification",
+		args: []kwarg{
+			{"channel", target.Channel},
+			{"text", n.message},
+			{"slack_conn_id", connIDOr(target, "slack_api_default")},
+		},
+	}
+}
+
+// slackWebhookNotifier posts to the channel the webhook was created for.
+func slackWebhookNotifier(target entity.NotificationTarget, n notification) operatorCall {
+	return operatorCall{
+		class: "airflow.providers.slack.notifications.slack_webhook.send_slack_webhook_notification",
+		args: []kwarg{
+			{"text", n.message},
+			{"slack_webhook_conn_id", connIDOr(target, "slack_default")},
+		},
+	}
+}
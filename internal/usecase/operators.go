@@ -0,0 +1,519 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/entity"
+	"github.com/Suhaibshah22/pipeweaver/internal/usecase/templates"
+)
+
+// Operator is the Airflow operator a step runs as, ready to be rendered:
+//
+//	{{.Class}}(task_id=..., {{.Name}}={{.Value}}, ..., dag=dag)
+type Operator struct {
+	Class     templates.Python
+	Imports   []templates.Python // e.g. from airflow.operators.bash import BashOperator
+	Arguments []OperatorArgument // keyword arguments besides task_id and dag
+}
+
+type OperatorArgument struct {
+	Name  templates.Python
+	Value templates.Python
+}
+
+// operatorCall is an operator by its import path, e.g.
+// airflow.operators.bash.BashOperator, and the arguments it is called with.
+type operatorCall struct {
+	class   string
+	args    []kwarg
+	imports []string // further import paths, e.g. of a python_callable
+}
+
+type kwarg struct {
+	name  string
+	value any
+}
+
+// operatorBuilder maps a step to the operator running it. Config holds the
+// step config the builder did not consume, which is passed on to the operator
+// as keyword arguments.
+type operatorBuilder func(step entity.Step, config map[string]any) (operatorCall, error)
+
+// stepOperators maps step types to the Airflow operators running them.
+var stepOperators = map[string]operatorBuilder{
+	"ingestion":      ingestionOperator,
+	"transformation": sqlOperator,
+	"sql":            sqlOperator,
+	"bash":           bashOperator,
+	"python":         pythonOperator,
+	"kubernetes_pod": kubernetesPodOperator,
+}
+
+// defaultConnIDs are the Airflow connections used for data refs without a conn_id.
+var defaultConnIDs = map[string]string{
+	"postgres":  "postgres_default",
+	"mysql":     "mysql_default",
+	"snowflake": "snowflake_default",
+	"bigquery":  "google_cloud_default",
+	"redshift":  "redshift_default",
+	"s3":        "aws_default",
+	"gcs":       "google_cloud_default",
+}
+
+// databases can run SQL through SQLExecuteQueryOperator.
+var databases = []string{"postgres", "mysql", "snowflake", "bigquery", "redshift"}
+
+var (
+	pythonIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	pythonImportPath = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)+$`)
+)
+
+// Operator returns the Airflow operator running the task's step, picked by
+// step type. A step can name any other operator with config.operator, every
+// other config key is passed to the operator as a keyword argument.
+func (t DAGTask) Operator() (*Operator, error) {
+	operator, err := buildOperator(t.Step)
+	if err != nil {
+		return nil, fmt.Errorf("step %q: %w", t.Step.Name, err)
+	}
+	return operator, nil
+}
+
+// Imports returns the imports of every task's operator and of the DAG's
+// datasets and notifiers, sorted and without duplicates, for the top of the DAG file.
+func (d DAGTemplateData) Imports() ([]templates.Python, error) {
+	var imports []templates.Python
+	names := map[string]templates.Python{}
+	add := func(source string, statements []templates.Python) error {
+		for _, imported := range statements {
+			// from a import load and from b import load would shadow each other
+			_, name, _ := strings.Cut(string(imported), " import ")
+			if previous, ok := names[name]; ok && previous != imported {
+				return fmt.Errorf("%s: %s conflicts with %s", source, imported, previous)
+			}
+			names[name] = imported
+			imports = append(imports, imported)
+		}
+		return nil
+	}
+
+	schedule, err := d.Schedule()
+	if err != nil {
+		return nil, err
+	}
+	if schedule.datasets {
+		if err := add("pipeline schedule", importStatements([]string{"airflow.datasets.Dataset"})); err != nil {
+			return nil, err
+		}
+	}
+	_, notifierImports, err := notificationCallbacks(d.Pipeline.Notifications, dagNotifications)
+	if err != nil {
+		return nil, fmt.Errorf("pipeline notifications: %w", err)
+	}
+	if err := add("pipeline notifications", importStatements(notifierImports)); err != nil {
+		return nil, err
+	}
+	for _, task := range d.Tasks {
+		operator, err := task.Operator()
+		if err != nil {
+			return nil, err
+		}
+		if err := add(fmt.Sprintf("step %q", task.Step.Name), operator.Imports); err != nil {
+			return nil, err
+		}
+	}
+	slices.Sort(imports)
+	return slices.Compact(imports), nil
+}
+
+func buildOperator(step entity.Step) (*Operator, error) {
+	// 1. Copy the config, builders consume the keys they understand
+	config := map[string]any{}
+	switch c := step.Config.(type) {
+	case nil:
+	case map[string]any:
+		config = maps.Clone(c)
+	default:
+		return nil, errors.New("config must be a mapping of operator arguments")
+	}
+
+	// 2. Build the operator call for the step type, unless config names one
+	var call operatorCall
+	if class, ok := config["operator"]; ok {
+		delete(config, "operator")
+		path, _ := class.(string)
+		if !pythonImportPath.MatchString(path) {
+			return nil, fmt.Errorf("config.operator must be the import path of an operator, e.g. airflow.operators.bash.BashOperator, got %v", class)
+		}
+		call = operatorCall{class: path}
+	} else {
+		build, ok := stepOperators[step.Type]
+		if !ok {
+			return nil, fmt.Errorf("no Airflow operator for step type %q, set config.operator to choose one", step.Type)
+		}
+		var err error
+		if call, err = build(step, config); err != nil {
+			return nil, err
+		}
+	}
+
+	// 3. Notify the step's targets when the task finishes
+	callbacks, imports, err := notificationCallbacks(step.Notifications, taskNotifications)
+	if err != nil {
+		return nil, fmt.Errorf("notifications: %w", err)
+	}
+	call.args = append(call.args, callbacks...)
+	call.imports = append(call.imports, imports...)
+
+	// 4. Pass the remaining config on, overriding generated arguments
+	names := make([]string, 0, len(config))
+	for name := range config {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if !pythonIdentifier.MatchString(name) || name == "task_id" || name == "dag" {
+			return nil, fmt.Errorf("config.%s is not a valid operator argument", name)
+		}
+		i := slices.IndexFunc(call.args, func(arg kwarg) bool { return arg.name == name })
+		if i >= 0 {
+			call.args[i].value = config[name]
+		} else {
+			call.args = append(call.args, kwarg{name, config[name]})
+		}
+	}
+
+	// 5. Render the call as Python
+	_, class := splitImportPath(call.class)
+	operator := &Operator{
+		Class:   templates.Python(class),
+		Imports: importStatements(append([]string{call.class}, call.imports...)),
+	}
+	if operator.Arguments, err = renderArguments(call.args); err != nil {
+		return nil, err
+	}
+	return operator, nil
+}
+
+// renderArguments renders keyword arguments as Python.
+func renderArguments(args []kwarg) ([]OperatorArgument, error) {
+	rendered := make([]OperatorArgument, 0, len(args))
+	for _, arg := range args {
+		value, err := templates.Literal(arg.value)
+		if err != nil {
+			return nil, fmt.Errorf("config.%s: %w", arg.name, err)
+		}
+		rendered = append(rendered, OperatorArgument{Name: templates.Python(arg.name), Value: value})
+	}
+	return rendered, nil
+}
+
+// importStatements turns import paths into Python imports.
+func importStatements(paths []string) []templates.Python {
+	imports := make([]templates.Python, len(paths))
+	for i, path := range paths {
+		module, name := splitImportPath(path)
+		imports[i] = templates.Python("from " + module + " import " + name)
+	}
+	return imports
+}
+
+func splitImportPath(path string) (module, name string) {
+	i := strings.LastIndexByte(path, '.')
+	if i < 0 {
+		return "", path
+	}
+	return path[:i], path[i+1:]
+}
+
+// take removes a key from config, returning its value.
+func take(config map[string]any, key string) (any, bool) {
+	value, ok := config[key]
+	delete(config, key)
+	return value, ok
+}
+
+func bashOperator(step entity.Step, config map[string]any) (operatorCall, error) {
+	if config["bash_command"] == nil {
+		return operatorCall{}, errors.New("bash steps need config.bash_command")
+	}
+	return operatorCall{class: "airflow.operators.bash.BashOperator"}, nil
+}
+
+func pythonOperator(step entity.Step, config map[string]any) (operatorCall, error) {
+	value, _ := take(config, "callable")
+	path, _ := value.(string)
+	if !pythonImportPath.MatchString(path) {
+		return operatorCall{}, fmt.Errorf("python steps need config.callable, the import path of the function to run, e.g. my_package.tasks.load")
+	}
+	_, name := splitImportPath(path)
+	return operatorCall{
+		class:   "airflow.operators.python.PythonOperator",
+		args:    []kwarg{{"python_callable", templates.Python(name)}},
+		imports: []string{path},
+	}, nil
+}
+
+func kubernetesPodOperator(step entity.Step, config map[string]any) (operatorCall, error) {
+	if config["image"] == nil {
+		return operatorCall{}, errors.New("kubernetes_pod steps need config.image")
+	}
+	// Pod names must be DNS labels
+	name := strings.Trim(nonIdentifierChars.ReplaceAllString(strings.ToLower(strings.ReplaceAll(step.Name, "_", "-")), "-"), "-")
+	if name == "" {
+		name = "task"
+	}
+	return operatorCall{
+		class: "airflow.providers.cncf.kubernetes.operators.pod.KubernetesPodOperator",
+		args:  []kwarg{{"name", name}},
+	}, nil
+}
+
+// sqlOperator runs the step's query on its first database output, or input.
+func sqlOperator(step entity.Step, config map[string]any) (operatorCall, error) {
+	call := operatorCall{class: "airflow.providers.common.sql.operators.sql.SQLExecuteQueryOperator"}
+	if query := stepQuery(step); query != "" {
+		call.args = append(call.args, kwarg{"sql", query})
+	} else if config["sql"] == nil {
+		return operatorCall{}, fmt.Errorf("%s steps need a transformation_query, transformation_query_file or config.sql", step.Type)
+	}
+
+	refs := append(slices.Clone(step.Outputs), step.Inputs...)
+	i := slices.IndexFunc(refs, func(ref entity.DataRef) bool { return slices.Contains(databases, ref.Type) })
+	switch {
+	case i >= 0:
+		call.args = append(call.args, kwarg{"conn_id", connID(refs[i])})
+		if refs[i].Database != "" {
+			call.args = append(call.args, kwarg{"database", refs[i].Database})
+		}
+	case config["conn_id"] == nil:
+		return operatorCall{}, fmt.Errorf("%s steps need a database input or output, or config.conn_id", step.Type)
+	}
+	return call, nil
+}
+
+// transfer moves data from one type of system to another.
+type transfer struct {
+	from, to []string
+	build    func(step entity.Step, from, to entity.DataRef, config map[string]any) (operatorCall, error)
+}
+
+var transfers = []transfer{
+	{from: []string{"s3"}, to: []string{"snowflake"}, build: s3ToSnowflake},
+	{from: []string{"s3"}, to: []string{"redshift"}, build: s3ToRedshift},
+	{from: []string{"gcs"}, to: []string{"bigquery"}, build: gcsToBigQuery},
+	{from: []string{"postgres", "mysql"}, to: []string{"gcs"}, build: sqlToGCS},
+	{from: databases, to: []string{"s3"}, build: sqlToS3},
+	// BigQuery has no row by row inserts
+	{from: databases, to: []string{"postgres", "mysql", "snowflake", "redshift"}, build: genericTransfer},
+}
+
+// ingestionOperator copies the step's first input into its first output with
+// the transfer operator for their types.
+func ingestionOperator(step entity.Step, config map[string]any) (operatorCall, error) {
+	if len(step.Inputs) == 0 || len(step.Outputs) == 0 {
+		return operatorCall{}, errors.New("ingestion steps need an input and an output")
+	}
+	from, to := step.Inputs[0], step.Outputs[0]
+
+	var supported []string
+	for _, t := range transfers {
+		if slices.Contains(t.from, from.Type) && slices.Contains(t.to, to.Type) {
+			return t.build(step, from, to, config)
+		}
+		supported = append(supported, strings.Join(t.from, "/")+" to "+strings.Join(t.to, "/"))
+	}
+	return operatorCall{}, fmt.Errorf("no Airflow operator copies %s to %s, set config.operator to choose one (supported: %s)",
+		from.Type, to.Type, strings.Join(supported, ", "))
+}
+
+func connID(ref entity.DataRef) string {
+	if ref.ConnID != "" {
+		return ref.ConnID
+	}
+	return defaultConnIDs[ref.Type]
+}
+
+// stepQuery is the SQL a step runs: its transformation_query, or the name of
+// the copy of its transformation_query_file, which Airflow loads from the
+// template search path of the DAG.
+func stepQuery(step entity.Step) string {
+	if step.TransformationQueryFile != "" {
+		return sqlFileName(step)
+	}
+	return step.TransformationQuery
+}
+
+// sqlFileName names the copy of a step's transformation_query_file.
+func sqlFileName(step entity.Step) string {
+	return step.Name + ".sql"
+}
+
+// sourceQuery reads a step's input, the step's query when there is one.
+func sourceQuery(step entity.Step, from entity.DataRef) (string, error) {
+	if query := stepQuery(step); query != "" {
+		return query, nil
+	}
+	if from.TableName == "" {
+		return "", fmt.Errorf("input %q needs a table_name or the step a transformation_query", from.Name)
+	}
+	return "SELECT * FROM " + from.TableName, nil
+}
+
+func requireTable(ref entity.DataRef) error {
+	if ref.TableName == "" {
+		return fmt.Errorf("output %q needs a table_name", ref.Name)
+	}
+	return nil
+}
+
+// bucketAndKey splits an object storage path such as s3://bucket/prefix.
+func bucketAndKey(ref entity.DataRef, scheme string) (string, string, error) {
+	path, ok := strings.CutPrefix(ref.Path, scheme+"://")
+	bucket, key, _ := strings.Cut(path, "/")
+	if !ok || bucket == "" {
+		return "", "", fmt.Errorf("%q needs a path such as %s://bucket/prefix", ref.Name, scheme)
+	}
+	return bucket, key, nil
+}
+
+func genericTransfer(step entity.Step, from, to entity.DataRef, config map[string]any) (operatorCall, error) {
+	query, err := sourceQuery(step, from)
+	if err != nil {
+		return operatorCall{}, err
+	}
+	if err := requireTable(to); err != nil {
+		return operatorCall{}, err
+	}
+	return operatorCall{
+		class: "airflow.operators.generic_transfer.GenericTransfer",
+		args: []kwarg{
+			{"sql", query},
+			{"source_conn_id", connID(from)},
+			{"destination_table", to.TableName},
+			{"destination_conn_id", connID(to)},
+		},
+	}, nil
+}
+
+func sqlToS3(step entity.Step, from, to entity.DataRef, config map[string]any) (operatorCall, error) {
+	query, err := sourceQuery(step, from)
+	if err != nil {
+		return operatorCall{}, err
+	}
+	bucket, key, err := bucketAndKey(to, "s3")
+	if err != nil {
+		return operatorCall{}, err
+	}
+	return operatorCall{
+		class: "airflow.providers.amazon.aws.transfers.sql_to_s3.SqlToS3Operator",
+		args: []kwarg{
+			{"query", query},
+			{"sql_conn_id", connID(from)},
+			{"s3_bucket", bucket},
+			{"s3_key", key},
+			{"aws_conn_id", connID(to)},
+		},
+	}, nil
+}
+
+func sqlToGCS(step entity.Step, from, to entity.DataRef, config map[string]any) (operatorCall, error) {
+	query, err := sourceQuery(step, from)
+	if err != nil {
+		return operatorCall{}, err
+	}
+	bucket, key, err := bucketAndKey(to, "gs")
+	if err != nil {
+		return operatorCall{}, err
+	}
+
+	class, connArg := "airflow.providers.google.cloud.transfers.postgres_to_gcs.PostgresToGCSOperator", "postgres_conn_id"
+	if from.Type == "mysql" {
+		class, connArg = "airflow.providers.google.cloud.transfers.mysql_to_gcs.MySQLToGCSOperator", "mysql_conn_id"
+	}
+	return operatorCall{
+		class: class,
+		args: []kwarg{
+			{"sql", query},
+			{connArg, connID(from)},
+			{"bucket", bucket},
+			{"filename", key},
+			{"gcp_conn_id", connID(to)},
+		},
+	}, nil
+}
+
+func s3ToSnowflake(step entity.Step, from, to entity.DataRef, config map[string]any) (operatorCall, error) {
+	if err := requireTable(to); err != nil {
+		return operatorCall{}, err
+	}
+	// Snowflake reads S3 through an external stage, which the step config names
+	if config["stage"] == nil || config["file_format"] == nil {
+		return operatorCall{}, errors.New("loading s3 into snowflake needs config.stage and config.file_format")
+	}
+	call := operatorCall{
+		class: "airflow.providers.snowflake.transfers.copy_into_snowflake.CopyFromExternalStageToSnowflakeOperator",
+		args: []kwarg{
+			{"table", to.TableName},
+			{"snowflake_conn_id", connID(to)},
+		},
+	}
+	// The stage points at the bucket, the input's path narrows it down to
+	// the files below its prefix
+	if from.Path != "" {
+		_, key, err := bucketAndKey(from, "s3")
+		if err != nil {
+			return operatorCall{}, err
+		}
+		if key != "" {
+			call.args = append(call.args, kwarg{"prefix", key})
+		}
+	}
+	return call, nil
+}
+
+func s3ToRedshift(step entity.Step, from, to entity.DataRef, config map[string]any) (operatorCall, error) {
+	bucket, key, err := bucketAndKey(from, "s3")
+	if err != nil {
+		return operatorCall{}, err
+	}
+	schema, table, ok := strings.Cut(to.TableName, ".")
+	if !ok {
+		return operatorCall{}, fmt.Errorf("output %q needs a table_name such as schema.table", to.Name)
+	}
+	return operatorCall{
+		class: "airflow.providers.amazon.aws.transfers.s3_to_redshift.S3ToRedshiftOperator",
+		args: []kwarg{
+			{"s3_bucket", bucket},
+			{"s3_key", key},
+			{"aws_conn_id", connID(from)},
+			{"schema", schema},
+			{"table", table},
+			{"redshift_conn_id", connID(to)},
+		},
+	}, nil
+}
+
+func gcsToBigQuery(step entity.Step, from, to entity.DataRef, config map[string]any) (operatorCall, error) {
+	bucket, key, err := bucketAndKey(from, "gs")
+	if err != nil {
+		return operatorCall{}, err
+	}
+	if err := requireTable(to); err != nil {
+		return operatorCall{}, err
+	}
+	return operatorCall{
+		class: "airflow.providers.google.cloud.transfers.gcs_to_bigquery.GCSToBigQueryOperator",
+		args: []kwarg{
+			{"bucket", bucket},
+			{"source_objects", []string{key}},
+			{"destination_project_dataset_table", to.TableName},
+			{"gcp_conn_id", connID(to)},
+		},
+	}, nil
+}
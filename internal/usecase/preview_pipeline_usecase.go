@@ -0,0 +1,337 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Suhaibshah22/pipeweaver/cmd/config"
+	"github.com/Suhaibshah22/pipeweaver/external"
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/entity"
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/port/repository"
+	"github.com/Suhaibshah22/pipeweaver/util"
+)
+
+// DAG_PREVIEW_MARKER identifies the preview comment so re-runs edit it instead of posting a new one.
+const DAG_PREVIEW_MARKER = "<!-- pipeweaver:dag-preview -->"
+
+// GitHub rejects comments longer than 65536 characters, keep well clear of it.
+const maxPreviewDiffLength = 20000
+const maxPreviewCommentLength = 60000
+
+type PreviewPipelineUsecase interface {
+	Execute(ctx context.Context, payload external.GitHubPullRequestPayload) (*entity.JobResult, error)
+}
+
+type previewPipelineUsecase struct {
+	GitRepository             repository.GitRepository
+	GitHubService             external.GitHubService
+	GenerateAirFlowDAGUsecase GenerateAirFlowDAGUsecase
+
+	Config *config.Config
+	Log    *slog.Logger
+}
+
+func NewPreviewPipelineUsecase(
+	gitRepo repository.GitRepository,
+	gitHubService external.GitHubService,
+	generateAirFlowDAGUsecase GenerateAirFlowDAGUsecase,
+
+	logger *slog.Logger,
+	cfg *config.Config,
+) PreviewPipelineUsecase {
+	return &previewPipelineUsecase{
+		GitRepository:             gitRepo,
+		GitHubService:             gitHubService,
+		GenerateAirFlowDAGUsecase: generateAirFlowDAGUsecase,
+
+		Config: cfg,
+		Log:    logger,
+	}
+}
+
+// dagPreview is the outcome of rendering one pipeline file for the preview comment.
+type dagPreview struct {
+	PipelinePath string
+	DAGPath      string
+	Diff         string
+	New          bool
+	Removed      bool
+	Warnings     []string
+	Err          error
+}
+
+// Execute renders the DAGs for every pipeline changed by a pull request and
+// posts the diff against the base branch as a PR comment. Nothing is committed
+// or pushed.
+func (uc *previewPipelineUsecase) Execute(ctx context.Context, payload external.GitHubPullRequestPayload) (*entity.JobResult, error) {
+	result := &entity.JobResult{PullRequestURL: payload.PullRequest.HTMLURL}
+	owner := payload.Repository.Owner.Login
+	repoName := payload.Repository.Name
+	number := payload.Number
+
+	// 1. Find the pipeline files touched by the pull request
+	files, err := uc.GitHubService.ListPullRequestFiles(owner, repoName, number, uc.Config.Git.Token)
+	if err != nil {
+		return result, fmt.Errorf("failed to list pull request files: %w", err)
+	}
+
+	var changedPipelines, removedPipelines, sqlPaths []string
+	for _, file := range files {
+		// A renamed pipeline drops the DAG generated from its previous path
+		previous := file.GetPreviousFilename()
+		if file.GetStatus() == "renamed" && isPipelineFile(previous) &&
+			DAGPathFor(previous) != DAGPathFor(file.GetFilename()) {
+			removedPipelines = append(removedPipelines, previous)
+		}
+
+		for _, changed := range []string{file.GetFilename(), previous} {
+			if path.Ext(changed) == ".sql" {
+				sqlPaths = append(sqlPaths, changed)
+			}
+		}
+		if !isPipelineFile(file.GetFilename()) {
+			continue
+		}
+		if file.GetStatus() == "removed" {
+			removedPipelines = append(removedPipelines, file.GetFilename())
+		} else {
+			changedPipelines = append(changedPipelines, file.GetFilename())
+		}
+	}
+	if len(changedPipelines) == 0 && len(removedPipelines) == 0 && len(sqlPaths) == 0 {
+		uc.Log.Info("No pipeline files changed in pull request. Skipping preview.", "pullRequest", number)
+		result.SkipReason = "no pipeline files changed"
+		return result, nil
+	}
+
+	// 2. Fetch the pull request head and its base branch
+	headRef := fmt.Sprintf("refs/remotes/origin/pr/%d", number)
+	baseRef := "refs/remotes/origin/" + payload.PullRequest.Base.Ref
+	err = uc.GitRepository.Fetch(ctx,
+		fmt.Sprintf("+refs/pull/%d/head:%s", number, headRef),
+		fmt.Sprintf("+refs/heads/%s:%s", payload.PullRequest.Base.Ref, baseRef),
+	)
+	if err != nil {
+		return result, err
+	}
+
+	// Pipelines reading a changed SQL file are previewed along with it
+	readers, err := pipelinesReading(ctx, uc.GitRepository, sqlPaths, headRef)
+	if err != nil {
+		uc.Log.Warn("Unable to find pipelines reading changed SQL files", "files", sqlPaths, "error", err)
+	}
+	for _, reader := range readers {
+		if !slices.Contains(changedPipelines, reader) {
+			changedPipelines = append(changedPipelines, reader)
+		}
+	}
+	if len(changedPipelines) == 0 && len(removedPipelines) == 0 {
+		uc.Log.Info("No pipelines read the SQL files changed in pull request. Skipping preview.", "pullRequest", number)
+		result.SkipReason = "no pipeline files changed"
+		return result, nil
+	}
+
+	// 3. Render each pipeline at the head and diff it against the base
+	previews := make([]dagPreview, 0, len(changedPipelines)+len(removedPipelines))
+	for _, filePath := range changedPipelines {
+		uc.Log.Info("Rendering preview for file", "filePath", filePath, "pullRequest", number)
+		previews = append(previews, uc.preview(ctx, filePath, headRef, baseRef))
+	}
+	for _, filePath := range removedPipelines {
+		previews = append(previews, uc.previewRemoval(ctx, filePath, baseRef))
+	}
+	for _, preview := range previews {
+		result.Files = append(result.Files, preview.fileResult())
+	}
+
+	// 4. Create or update the preview comment
+	body := renderPreviewComment(payload.PullRequest.Head.SHA, previews)
+
+	existing, err := uc.GitHubService.FindIssueComment(owner, repoName, number, DAG_PREVIEW_MARKER, uc.Config.Git.Token)
+	if err != nil {
+		return result, fmt.Errorf("failed to look up preview comment: %w", err)
+	}
+	if existing != nil {
+		_, err = uc.GitHubService.EditIssueComment(owner, repoName, existing.GetID(), body, uc.Config.Git.Token)
+	} else {
+		_, err = uc.GitHubService.CreateIssueComment(owner, repoName, number, body, uc.Config.Git.Token)
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to post preview comment: %w", err)
+	}
+
+	uc.Log.Info("DAG preview posted", "pullRequest", number, "pipelines", len(previews))
+	return result, nil
+}
+
+func (uc *previewPipelineUsecase) preview(ctx context.Context, filePath, headRef, baseRef string) dagPreview {
+	preview := dagPreview{
+		PipelinePath: filePath,
+		DAGPath:      DAGPathFor(filePath),
+	}
+
+	file, err := uc.GitRepository.FindByPathAtRevision(ctx, filePath, headRef)
+	if err != nil {
+		preview.Err = err
+		return preview
+	}
+
+	dag, err := uc.GenerateAirFlowDAGUsecase.Execute(ctx, file.Content, filePath, filesAt(uc.GitRepository, headRef))
+	if err != nil {
+		preview.Err = err
+		return preview
+	}
+	preview.Warnings = dag.AllWarnings()
+
+	var currentContent []byte
+	current, err := uc.GitRepository.FindByPathAtRevision(ctx, preview.DAGPath, baseRef)
+	switch {
+	case errors.Is(err, repository.ErrFileNotFound):
+		preview.New = true
+	case err != nil:
+		preview.Err = err
+		return preview
+	default:
+		currentContent = current.Content
+	}
+
+	preview.Diff, preview.Err = util.UnifiedDiff(preview.DAGPath, currentContent, preview.DAGPath, dag.Content)
+	return preview
+}
+
+// previewRemoval shows the generated DAG that merging would delete.
+func (uc *previewPipelineUsecase) previewRemoval(ctx context.Context, filePath, baseRef string) dagPreview {
+	preview := dagPreview{
+		PipelinePath: filePath,
+		DAGPath:      DAGPathFor(filePath),
+		Removed:      true,
+	}
+
+	current, err := uc.GitRepository.FindByPathAtRevision(ctx, preview.DAGPath, baseRef)
+	if errors.Is(err, repository.ErrFileNotFound) {
+		return preview
+	}
+	if err != nil {
+		preview.Err = err
+		return preview
+	}
+
+	preview.Diff, preview.Err = util.UnifiedDiff(preview.DAGPath, current.Content, preview.DAGPath, nil)
+	return preview
+}
+
+// fileResult reports the preview as the job result for its pipeline file.
+func (p dagPreview) fileResult() entity.FileResult {
+	result := entity.FileResult{
+		PipelinePath: p.PipelinePath,
+		DAGPath:      p.DAGPath,
+		Action:       "previewed",
+		Status:       entity.JobSucceeded,
+		Warnings:     p.Warnings,
+	}
+	if p.Removed {
+		result.Action = "previewed_removal"
+	}
+	if p.Err != nil {
+		result.Status = entity.JobFailed
+		result.Error = p.Err.Error()
+	}
+	return result
+}
+
+func renderPreviewComment(headSHA string, previews []dagPreview) string {
+	var b strings.Builder
+	b.WriteString(DAG_PREVIEW_MARKER + "\n")
+	b.WriteString("### pipeweaver DAG preview\n\n")
+	fmt.Fprintf(&b, "Rendered from `%s`. Nothing has been committed, DAGs are generated once this pull request is merged.\n", shortSHA(headSHA))
+
+	for i, preview := range previews {
+		section := renderPreviewSection(preview)
+		if b.Len()+len(section) > maxPreviewCommentLength {
+			fmt.Fprintf(&b, "\n_%d more pipeline(s) omitted, the preview is too large for a comment._\n", len(previews)-i)
+			break
+		}
+		b.WriteString(section)
+	}
+
+	return b.String()
+}
+
+func renderPreviewSection(preview dagPreview) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n#### `%s` → `%s`\n\n", preview.PipelinePath, preview.DAGPath)
+
+	switch {
+	case preview.Err != nil:
+		fmt.Fprintf(&b, ":x: DAG generation failed:\n\n%s", codeBlock("", preview.Err.Error()))
+	case preview.Removed && preview.Diff == "":
+		b.WriteString("Pipeline removed, there is no generated DAG to delete.\n")
+	case preview.Diff == "":
+		b.WriteString("No changes to the generated DAG.\n")
+	default:
+		if preview.New {
+			b.WriteString("New DAG.\n\n")
+		}
+		if preview.Removed {
+			b.WriteString("Pipeline removed, merging deletes its DAG.\n\n")
+		}
+		diff := preview.Diff
+		if len(diff) > maxPreviewDiffLength {
+			diff = truncateLines(diff, maxPreviewDiffLength) + "\n... diff truncated ..."
+		}
+		b.WriteString(codeBlock("diff", strings.TrimRight(diff, "\n")))
+	}
+
+	if len(preview.Warnings) > 0 {
+		b.WriteString("\n")
+		for _, warning := range preview.Warnings {
+			fmt.Fprintf(&b, "- :warning: %s\n", warning)
+		}
+	}
+
+	return b.String()
+}
+
+// truncateLines cuts text down to at most limit bytes, after the last whole
+// line that fits, or at a rune boundary when not even one line does.
+func truncateLines(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	if i := strings.LastIndexByte(text[:limit], '\n'); i > 0 {
+		return text[:i]
+	}
+	for limit > 0 && !utf8.RuneStart(text[limit]) {
+		limit--
+	}
+	return text[:limit]
+}
+
+// codeBlock fences content as a Markdown code block, with a fence longer than
+// any run of backticks in it so the content cannot close the block early.
+func codeBlock(info, content string) string {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	return fence + info + "\n" + content + "\n" + fence + "\n"
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -3,27 +3,50 @@ package usecase
 import (
 	"context"
 	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"log/slog"
 	"math/big"
+	mrand "math/rand/v2"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/Suhaibshah22/pipeweaver/cmd/config"
 	"github.com/Suhaibshah22/pipeweaver/external"
 	"github.com/Suhaibshah22/pipeweaver/internal/domain/entity"
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/port/queue"
 	"github.com/Suhaibshah22/pipeweaver/internal/domain/port/repository"
 	"github.com/Suhaibshah22/pipeweaver/util"
+
+	"github.com/google/go-github/v50/github"
 )
 
 // Corrected paths to reflect the correct structure in the repository
 const PIPELINES_DIRECTORY = "pipelines/"
 const OUTPUT_DIRECTORY = "airflow-dags/"
 
-var ProcessPipelinesQueue chan external.GitHubWebhookPayload
+// BRANCH_PREFIX names the branches DAGs are pushed to, followed by the pushed commit.
+const BRANCH_PREFIX = "pipeweaver/dags-"
+
+// PipelineEvent is a webhook delivery waiting to be processed by the queue worker.
+type PipelineEvent struct {
+	DeliveryID  string                             `json:"delivery_id"`
+	Event       string                             `json:"event"`
+	Push        *external.GitHubPushPayload        `json:"push,omitempty"`
+	PullRequest *external.GitHubPullRequestPayload `json:"pull_request,omitempty"`
+}
 
 type ProcessPipelineUsecase interface {
-	execute(ctx context.Context, payload external.GitHubWebhookPayload) error
+	// Enqueue durably stores an event for the queue worker and returns its job ID.
+	// An event that was already received returns the existing job ID and
+	// queue.ErrJobExists, unless that job failed, in which case it is redriven.
+	Enqueue(ctx context.Context, event PipelineEvent) (string, error)
+	execute(ctx context.Context, payload external.GitHubPushPayload) (*entity.JobResult, error)
 	StartQueue(ctx context.Context)
 }
 
@@ -31,6 +54,8 @@ type processPipelineUsecase struct {
 	GitRepository             repository.GitRepository
 	GitHubService             external.GitHubService
 	GenerateAirFlowDAGUsecase GenerateAirFlowDAGUsecase
+	PreviewPipelineUsecase    PreviewPipelineUsecase
+	JobQueue                  queue.JobQueue
 
 	Config *config.Config
 	Log    *slog.Logger
@@ -40,58 +65,197 @@ func NewProcessPipelineUsecase(
 	gitRepo repository.GitRepository,
 	gitHubService external.GitHubService,
 	generateAirFlowDAGUsecase GenerateAirFlowDAGUsecase,
+	previewPipelineUsecase PreviewPipelineUsecase,
+	jobQueue queue.JobQueue,
 
 	logger *slog.Logger,
 	cfg *config.Config,
 ) ProcessPipelineUsecase {
-	ProcessPipelinesQueue = make(chan external.GitHubWebhookPayload, 100)
-
 	return &processPipelineUsecase{
 		GitRepository:             gitRepo,
 		GitHubService:             gitHubService,
 		GenerateAirFlowDAGUsecase: generateAirFlowDAGUsecase,
+		PreviewPipelineUsecase:    previewPipelineUsecase,
+		JobQueue:                  jobQueue,
 
 		Config: cfg,
 		Log:    logger,
 	}
 }
 
+func (uc *processPipelineUsecase) Enqueue(ctx context.Context, event PipelineEvent) (string, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	// The delivery ID doubles as the job ID so callers can look up a delivery
+	id := event.DeliveryID
+	if id == "" {
+		id = randomString(16)
+	}
+
+	job := &entity.Job{
+		ID:         id,
+		DeliveryID: event.DeliveryID,
+		Event:      event.Event,
+		Payload:    payload,
+		DedupKey:   dedupKey(event),
+	}
+	err = uc.JobQueue.Enqueue(ctx, job)
+	if !errors.Is(err, queue.ErrJobExists) {
+		return id, err
+	}
+
+	// Redelivered delivery or the same push again, find the job that has it
+	existing, findErr := uc.JobQueue.Find(ctx, id)
+	if errors.Is(findErr, queue.ErrJobNotFound) {
+		existing, findErr = uc.JobQueue.FindByDedupKey(ctx, job.DedupKey)
+	}
+	if findErr != nil {
+		return id, findErr
+	}
+	if existing.Status != entity.JobFailed {
+		return existing.ID, err
+	}
+
+	// Run a failed job again rather than ignoring the redelivery
+	if err := uc.JobQueue.Redrive(ctx, existing.ID); err != nil {
+		return existing.ID, err
+	}
+	uc.Log.Info("Redriving failed job for redelivered event", "job", existing.ID, "delivery", event.DeliveryID)
+	return existing.ID, nil
+}
+
+// dedupKey identifies the work an event asks for. Pushes are keyed on the
+// commit they moved the branch to, so the same push is only processed once.
+func dedupKey(event PipelineEvent) string {
+	if event.Event != external.GitHubEventPush || event.Push == nil || event.Push.After == "" {
+		return ""
+	}
+
+	repo := event.Push.Repository
+	return fmt.Sprintf("push:%s/%s:%s", repo.Owner.Login, repo.Name, event.Push.After)
+}
+
 func (uc *processPipelineUsecase) StartQueue(ctx context.Context) {
 	for {
-		select {
-		case payload := <-ProcessPipelinesQueue:
-			err := uc.execute(ctx, payload)
-			if err != nil {
-				uc.Log.Error("Error processing pipeline", "error", err)
-			}
-		case <-ctx.Done():
+		job, err := uc.JobQueue.Dequeue(ctx)
+		if ctx.Err() != nil || errors.Is(err, queue.ErrQueueClosed) {
 			uc.Log.Info("Queue processor shutting down...")
 			return
 		}
+		if err != nil {
+			uc.Log.Error("Error dequeuing job", "error", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		uc.runJob(ctx, job)
+	}
+}
+
+// runJob processes one job and settles it: succeeded or skipped on success,
+// retried with backoff on transient failures, failed otherwise. Jobs interrupted
+// by shutdown are left unacknowledged so they run again on the next start.
+func (uc *processPipelineUsecase) runJob(ctx context.Context, job *entity.Job) {
+	var event PipelineEvent
+	var result *entity.JobResult
+	err := json.Unmarshal(job.Payload, &event)
+	if err == nil {
+		result, err = uc.dispatch(ctx, event)
+	}
+
+	if ctx.Err() != nil {
+		uc.Log.Info("Job interrupted by shutdown, it will be replayed", "job", job.ID, "delivery", job.DeliveryID)
+		return
+	}
+
+	switch {
+	case err == nil:
+		status := entity.JobSucceeded
+		if result != nil && result.SkipReason != "" {
+			status = entity.JobSkipped
+		}
+		err = uc.JobQueue.Ack(ctx, job.ID, status, result)
+	case util.IsRetryable(err) && job.Attempts < uc.Config.Queue.MaxAttempts:
+		delay := retryDelay(job.Attempts, uc.Config.Queue.InitialBackoff, uc.Config.Queue.MaxBackoff)
+		uc.Log.Warn("Error processing pipeline, retrying", "job", job.ID, "delivery", job.DeliveryID, "event", job.Event,
+			"attempt", job.Attempts, "retryIn", delay.String(), "error", err)
+		err = uc.JobQueue.Retry(ctx, job.ID, time.Now().Add(delay), err.Error(), result)
+	default:
+		uc.Log.Error("Error processing pipeline, marking job as failed", "job", job.ID, "delivery", job.DeliveryID, "event", job.Event,
+			"attempt", job.Attempts, "retryable", util.IsRetryable(err), "error", err)
+		err = uc.JobQueue.DeadLetter(ctx, job.ID, err.Error(), result)
+	}
+	if err != nil {
+		uc.Log.Error("Error settling job", "job", job.ID, "error", err)
+	}
+}
+
+// retryDelay returns the exponential backoff before the next attempt, with
+// jitter so jobs that failed together do not all retry at the same moment.
+func retryDelay(attempt int, initial, max time.Duration) time.Duration {
+	delay := initial
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
 	}
+	if delay <= 0 {
+		return 0
+	}
+
+	// Somewhere between half and all of the backoff
+	return delay/2 + mrand.N(delay/2+1)
 }
 
-func (uc *processPipelineUsecase) execute(ctx context.Context, payload external.GitHubWebhookPayload) error {
-	// Only process the repository if the event is a push to the main branch
-	if payload.Ref != "refs/heads/main" {
+func (uc *processPipelineUsecase) dispatch(ctx context.Context, event PipelineEvent) (*entity.JobResult, error) {
+	switch {
+	case event.Event == external.GitHubEventPush && event.Push != nil:
+		return uc.execute(ctx, *event.Push)
+	case event.Event == external.GitHubEventPullRequest && event.PullRequest != nil:
+		return uc.PreviewPipelineUsecase.Execute(ctx, *event.PullRequest)
+	default:
+		uc.Log.Info("Ignoring unsupported event", "delivery", event.DeliveryID, "event", event.Event)
+		return &entity.JobResult{SkipReason: "unsupported event " + event.Event}, nil
+	}
+}
+
+func (uc *processPipelineUsecase) execute(ctx context.Context, payload external.GitHubPushPayload) (*entity.JobResult, error) {
+	result := &entity.JobResult{}
+
+	// Only process the repository if the event is a push to the default branch
+	defaultBranch := uc.Config.Git.DefaultBranch
+	if payload.Ref != "refs/heads/"+defaultBranch {
 		uc.Log.Info("Ignoring event", "event", payload.Ref)
-		return nil
+		result.SkipReason = "push to " + payload.Ref + " is not on the default branch " + defaultBranch
+		return result, nil
+	}
+
+	// Bring the local clone up to date so every pushed commit is available
+	err := uc.GitRepository.Pull(ctx)
+	if err != nil {
+		return result, err
 	}
 
-	// Extract modified files
-	modifiedPipelines := payload.HeadCommit.Modified
-	modifiedPipelines = util.FilterByPrefix(modifiedPipelines, "pipelines/")
-	if len(modifiedPipelines) == 0 {
-		log.Print("No pipeline files modified. Skipping processing.")
-		return nil
+	// Extract the pipeline files changed across the whole push
+	changes := uc.changedPipelines(ctx, payload)
+	if len(changes) == 0 {
+		log.Print("No pipeline files changed. Skipping processing.")
+		result.SkipReason = "no pipeline files changed"
+		return result, nil
 	}
+	var summary pullRequestSummary
 
-	// 1. Create a new branch
-	newBranch := "pipeline-update-" + randomString(5)
-	err := uc.GitRepository.CreateBranch(ctx, newBranch)
+	// 1. Create the branch for this push, re-runs reuse it
+	newBranch := branchFor(payload.After)
+	err = uc.GitRepository.CreateBranch(ctx, newBranch)
 	if err != nil {
-		return err
+		return result, err
 	}
+	result.Branch = newBranch
 
 	// 2. Switch to the new branch
 	err = uc.GitRepository.SwitchBranch(ctx, newBranch)
@@ -99,78 +263,545 @@ func (uc *processPipelineUsecase) execute(ctx context.Context, payload external.
 		// Clean up in case of error
 		gitCleanUp(uc, ctx, newBranch)
 		uc.Log.Error("Error switching branch", "error", err)
+		return result, err
 	}
 
-	// 3. Process pipeline files
-	for _, filePath := range modifiedPipelines {
-		uc.Log.Info("Initiating processing for file", "filePath", filePath)
+	// 3. Generate DAGs for added, modified and renamed pipeline files
+	for _, change := range changes {
+		if change.Action == entity.FileRemoved {
+			continue
+		}
+		filePath := change.Path
+		dagPath := DAGPathFor(filePath)
+		uc.Log.Info("Initiating processing for file", "filePath", filePath, "action", change.Action)
 
-		// Read file content
-		file, err := uc.GitRepository.FindByPath(ctx, filePath)
+		// Read file content as of the pushed commit
+		file, err := uc.GitRepository.FindByPathAtRevision(ctx, filePath, payload.After)
 		if err != nil {
-			uc.Log.Error("FindByPath error", "filePath", filePath, "error", err)
+			uc.Log.Error("FindByPathAtRevision error", "filePath", filePath, "error", err)
+			result.Files = append(result.Files, failedFile(change, dagPath, err))
 			continue
 		}
 
 		// Pass the file content to generate the DAG
-		dagContent, err := uc.GenerateAirFlowDAGUsecase.Execute(ctx, file.Content, filePath)
+		dag, err := uc.GenerateAirFlowDAGUsecase.Execute(ctx, file.Content, filePath, filesAt(uc.GitRepository, payload.After))
 		if err != nil {
 			uc.Log.Error("GenerateAirflowDAGUsecase error", "filePath", filePath, "error", err)
+			result.Files = append(result.Files, failedFile(change, dagPath, err))
 			continue
 		}
 
-		// Determine the output path (trim prefix, then change .yaml to .py)
-		relativePath := strings.TrimPrefix(filePath, PIPELINES_DIRECTORY)
-		dagPath := filepath.Join(OUTPUT_DIRECTORY, relativePath)
-		dagPath = strings.TrimSuffix(dagPath, filepath.Ext(dagPath)) + ".py"
-
 		uc.Log.Debug("Generated DAG content", "dagPath", dagPath)
 
 		// Create the file in the Git repo
 		generatedDAGfile := &entity.File{
 			Path:    dagPath,
-			Content: []byte(dagContent),
+			Content: dag.Content,
 		}
 		err = uc.GitRepository.Update(ctx, generatedDAGfile)
 		if err != nil {
 			uc.Log.Error("Error creating file", "filePath", filePath, "error", err)
+			result.Files = append(result.Files, failedFile(change, dagPath, err))
 			continue
 		}
+		err = uc.writeSQLFiles(ctx, filePath, dag.SQLFiles)
+		if err != nil {
+			uc.Log.Error("Error writing SQL files", "filePath", filePath, "error", err)
+			result.Files = append(result.Files, failedFile(change, dagPath, err))
+			continue
+		}
+
+		if change.Action == entity.FileRenamed {
+			summary.Moved = append(summary.Moved, uc.moveDAG(ctx, change, file, payload.Before))
+		} else {
+			summary.Generated = append(summary.Generated, generatedDAG{PipelinePath: filePath, DAGPath: dagPath})
+		}
+		warnings := dag.AllWarnings()
+		for _, warning := range warnings {
+			summary.Warnings = append(summary.Warnings, pipelineWarning{PipelinePath: filePath, Message: warning})
+		}
+		result.Files = append(result.Files, entity.FileResult{
+			PipelinePath: filePath,
+			DAGPath:      dagPath,
+			Action:       string(change.Action),
+			Status:       entity.JobSucceeded,
+			Warnings:     warnings,
+		})
+	}
+
+	// 4. Remove the DAGs of deleted pipelines
+	for _, change := range changes {
+		if change.Action != entity.FileRemoved {
+			continue
+		}
+		filePath := change.Path
+		uc.Log.Info("Decommissioning pipeline", "filePath", filePath)
+
+		dagPath := DAGPathFor(filePath)
+		err := uc.GitRepository.Delete(ctx, dagPath)
+		if errors.Is(err, repository.ErrFileNotFound) {
+			uc.Log.Info("No generated DAG to remove", "filePath", filePath, "dagPath", dagPath)
+			result.Files = append(result.Files, entity.FileResult{
+				PipelinePath: filePath,
+				DAGPath:      dagPath,
+				Action:       string(change.Action),
+				Status:       entity.JobSkipped,
+			})
+			continue
+		}
+		if err != nil {
+			uc.Log.Error("Error removing DAG", "filePath", filePath, "dagPath", dagPath, "error", err)
+			result.Files = append(result.Files, failedFile(change, dagPath, err))
+			continue
+		}
+		if err := uc.removeSQLFiles(ctx, filePath); err != nil {
+			uc.Log.Error("Error removing SQL files", "filePath", filePath, "error", err)
+			result.Files = append(result.Files, failedFile(change, dagPath, err))
+			continue
+		}
+		result.Files = append(result.Files, entity.FileResult{
+			PipelinePath: filePath,
+			DAGPath:      dagPath,
+			Action:       string(change.Action),
+			Status:       entity.JobSucceeded,
+		})
+
+		summary.Decommissioned = append(summary.Decommissioned, decommissionedPipeline{
+			Name:         uc.pipelineNameAt(ctx, filePath, payload.Before),
+			PipelinePath: filePath,
+			DAGPath:      dagPath,
+		})
 	}
 
-	// 4. Commit and push changes
+	// 5. Commit and push changes
 	commitMessage := "Automated DAG Generation"
 	err = uc.GitRepository.CommitAndPush(ctx, commitMessage)
+	if errors.Is(err, repository.ErrNothingToCommit) {
+		// Every DAG is already up to date, or none could be generated
+		gitCleanUp(uc, ctx, newBranch)
+		result.Branch = ""
+
+		if failed := failedFiles(result); failed > 0 {
+			return result, fmt.Errorf("%d pipeline file(s) failed to generate", failed)
+		}
+		uc.Log.Info("Generated DAGs are already up to date. Skipping pull request.")
+		result.SkipReason = "generated DAGs are already up to date"
+		return result, nil
+	}
 	if err != nil {
 		// Clean up in case of error
 		gitCleanUp(uc, ctx, newBranch)
 
 		uc.Log.Error("Error committing and pushing changes", "error", err)
-		return err
+		return result, err
 	}
 
-	// 5. Create a pull request
-	err = createPullRequest(uc, ctx, payload, newBranch)
+	// 6. Create a pull request, or update the one opened by an earlier run
+	for _, file := range result.Files {
+		if file.Status == entity.JobFailed {
+			summary.Failed = append(summary.Failed, file)
+		}
+	}
+	pr, err := upsertPullRequest(uc, ctx, payload, newBranch, summary)
 	if err != nil {
 		uc.Log.Error("Error creating pull request", "error", err)
-		return err
+		return result, err
 	}
+	result.PullRequestURL = pr.GetHTMLURL()
 
-	// 6. Switch back to the main branch
+	// 7. Switch back to the default branch
 	gitCleanUp(uc, ctx, newBranch)
 
+	return result, nil
+}
+
+// failedFile records a pipeline file whose DAG could not be updated.
+func failedFile(change entity.FileChange, dagPath string, err error) entity.FileResult {
+	return entity.FileResult{
+		PipelinePath: change.Path,
+		DAGPath:      dagPath,
+		Action:       string(change.Action),
+		Status:       entity.JobFailed,
+		Error:        err.Error(),
+	}
+}
+
+func failedFiles(result *entity.JobResult) int {
+	failed := 0
+	for _, file := range result.Files {
+		if file.Status == entity.JobFailed {
+			failed++
+		}
+	}
+	return failed
+}
+
+// changedPipelines returns the pipeline files changed by a push. The local clone
+// is diffed between Before and After so every commit in the push is covered,
+// falling back to the file lists in the payload when that is not possible.
+func (uc *processPipelineUsecase) changedPipelines(ctx context.Context, payload external.GitHubPushPayload) []entity.FileChange {
+	changes, err := uc.GitRepository.ChangedFiles(ctx, payload.Before, payload.After)
+	if err != nil {
+		uc.Log.Warn("Unable to diff pushed commits, using payload commit lists", "before", payload.Before, "after", payload.After, "error", err)
+		changes = changesFromCommits(payload)
+	}
+
+	pipelineChanges := make([]entity.FileChange, 0, len(changes))
+	var sqlPaths []string
+	for _, change := range changes {
+		isPipeline := isPipelineFile(change.Path)
+		wasPipeline := change.Action == entity.FileRenamed && isPipelineFile(change.PreviousPath)
+		for _, changed := range []string{change.Path, change.PreviousPath} {
+			if path.Ext(changed) == ".sql" {
+				sqlPaths = append(sqlPaths, changed)
+			}
+		}
+
+		switch {
+		case isPipeline && change.Action == entity.FileRenamed && !wasPipeline:
+			// Moved into the pipelines directory, a new pipeline as far as we are concerned
+			pipelineChanges = append(pipelineChanges, entity.FileChange{Action: entity.FileAdded, Path: change.Path})
+		case !isPipeline && wasPipeline:
+			// Moved out of the pipelines directory, the pipeline is gone
+			pipelineChanges = append(pipelineChanges, entity.FileChange{Action: entity.FileRemoved, Path: change.PreviousPath})
+		case isPipeline:
+			pipelineChanges = append(pipelineChanges, change)
+		}
+	}
+
+	// Pipelines reading a changed SQL file are regenerated along with it
+	readers, err := pipelinesReading(ctx, uc.GitRepository, sqlPaths, payload.After)
+	if err != nil {
+		uc.Log.Warn("Unable to find pipelines reading changed SQL files", "files", sqlPaths, "error", err)
+	}
+	for _, reader := range readers {
+		if !slices.ContainsFunc(pipelineChanges, func(change entity.FileChange) bool { return change.Path == reader }) {
+			pipelineChanges = append(pipelineChanges, entity.FileChange{Action: entity.FileModified, Path: reader})
+		}
+	}
+	return pipelineChanges
+}
+
+// isPipelineFile reports whether a path is a pipeline definition.
+func isPipelineFile(filePath string) bool {
+	ext := path.Ext(filePath)
+	return strings.HasPrefix(filePath, PIPELINES_DIRECTORY) && (ext == ".yaml" || ext == ".yml")
+}
+
+// pipelinesReading returns the pipelines with a step whose
+// transformation_query_file is one of paths, as of a revision.
+func pipelinesReading(ctx context.Context, repo repository.GitRepository, paths []string, revision string) ([]string, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	files, err := repo.ListFilesAtRevision(ctx, PIPELINES_DIRECTORY, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	var pipelines []string
+	for _, filePath := range files {
+		if !isPipelineFile(filePath) {
+			continue
+		}
+		file, err := repo.FindByPathAtRevision(ctx, filePath, revision)
+		if err != nil {
+			return nil, err
+		}
+		upd, err := parseUPD(file.Content)
+		if err != nil {
+			// Invalid pipelines are reported when they are changed themselves
+			continue
+		}
+
+		reads := slices.ContainsFunc(upd.Pipeline.Steps, func(step entity.Step) bool {
+			queryPath, err := queryFilePath(filePath, step)
+			return step.TransformationQueryFile != "" && err == nil && slices.Contains(paths, queryPath)
+		})
+		if reads {
+			pipelines = append(pipelines, filePath)
+		}
+	}
+	return pipelines, nil
+}
+
+// moveDAG removes the DAG generated from a renamed pipeline's previous path,
+// once the DAG for its new path has been written.
+func (uc *processPipelineUsecase) moveDAG(ctx context.Context, change entity.FileChange, file *entity.File, before string) movedDAG {
+	moved := movedDAG{
+		PreviousPipelinePath: change.PreviousPath,
+		PipelinePath:         change.Path,
+		PreviousDAGPath:      DAGPathFor(change.PreviousPath),
+		DAGPath:              DAGPathFor(change.Path),
+		PreviousName:         uc.pipelineNameAt(ctx, change.PreviousPath, before),
+	}
+	if upd, err := parseUPD(file.Content); err == nil {
+		moved.Name = upd.Pipeline.Name
+	}
+
+	if moved.PreviousDAGPath != moved.DAGPath {
+		err := uc.GitRepository.Delete(ctx, moved.PreviousDAGPath)
+		if err != nil && !errors.Is(err, repository.ErrFileNotFound) {
+			uc.Log.Error("Error removing previous DAG", "filePath", change.PreviousPath, "dagPath", moved.PreviousDAGPath, "error", err)
+		}
+	}
+	if SQLDirFor(change.PreviousPath) != SQLDirFor(change.Path) {
+		if err := uc.removeSQLFiles(ctx, change.PreviousPath); err != nil {
+			uc.Log.Error("Error removing previous SQL files", "filePath", change.PreviousPath, "error", err)
+		}
+	}
+
+	return moved
+}
+
+// changesFromCommits folds the added/modified/removed lists of every commit in
+// a push into the net change per file.
+func changesFromCommits(payload external.GitHubPushPayload) []entity.FileChange {
+	commits := payload.Commits
+	if len(commits) == 0 {
+		commits = []external.GitHubCommit{payload.HeadCommit}
+	}
+
+	actions := map[string]entity.ChangeAction{}
+	var paths []string
+	record := func(path string, action entity.ChangeAction) {
+		previous, seen := actions[path]
+		if !seen {
+			paths = append(paths, path)
+		}
+
+		switch {
+		case previous == entity.FileAdded && action == entity.FileRemoved:
+			// Added and removed within the same push, nothing to do
+			delete(actions, path)
+		case previous == entity.FileAdded:
+			// Still a new file, however often it was modified afterwards
+		case previous == entity.FileRemoved && action == entity.FileAdded:
+			actions[path] = entity.FileModified
+		default:
+			actions[path] = action
+		}
+	}
+
+	for _, commit := range commits {
+		for _, path := range commit.Added {
+			record(path, entity.FileAdded)
+		}
+		for _, path := range commit.Modified {
+			record(path, entity.FileModified)
+		}
+		for _, path := range commit.Removed {
+			record(path, entity.FileRemoved)
+		}
+	}
+
+	changes := make([]entity.FileChange, 0, len(paths))
+	for _, path := range paths {
+		if action, ok := actions[path]; ok {
+			changes = append(changes, entity.FileChange{Action: action, Path: path})
+		}
+	}
+	return changes
+}
+
+// DAGPathFor maps a pipeline file to the DAG generated from it
+// (trim the pipelines prefix, then change .yaml to .py).
+func DAGPathFor(pipelinePath string) string {
+	relativePath := strings.TrimPrefix(pipelinePath, PIPELINES_DIRECTORY)
+	dagPath := filepath.Join(OUTPUT_DIRECTORY, relativePath)
+	return strings.TrimSuffix(dagPath, filepath.Ext(dagPath)) + ".py"
+}
+
+// writeSQLFiles replaces the SQL files copied for a pipeline's steps, dropping
+// those of steps that no longer have one.
+func (uc *processPipelineUsecase) writeSQLFiles(ctx context.Context, filePath string, files []entity.File) error {
+	if err := uc.removeSQLFiles(ctx, filePath); err != nil {
+		return err
+	}
+	for i := range files {
+		if err := uc.GitRepository.Update(ctx, &files[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeSQLFiles removes the SQL files copied for a pipeline's steps by
+// earlier runs, if any.
+func (uc *processPipelineUsecase) removeSQLFiles(ctx context.Context, filePath string) error {
+	sqlDir := SQLDirFor(filePath)
+	paths, err := uc.GitRepository.ListFilesAtRevision(ctx, sqlDir, "HEAD")
+	if err != nil {
+		return err
+	}
+	for _, sqlPath := range paths {
+		// Subdirectories belong to pipelines in a directory named like this one
+		if path.Dir(sqlPath) != sqlDir || path.Ext(sqlPath) != ".sql" {
+			continue
+		}
+		err := uc.GitRepository.Delete(ctx, sqlPath)
+		if err != nil && !errors.Is(err, repository.ErrFileNotFound) {
+			return err
+		}
+	}
 	return nil
 }
 
-func createPullRequest(uc *processPipelineUsecase, ctx context.Context, payload external.GitHubWebhookPayload, branch string) error {
+// filesAt reads the files of a repository as of a revision.
+func filesAt(repo repository.GitRepository, revision string) FileReader {
+	return func(ctx context.Context, filePath string) ([]byte, error) {
+		file, err := repo.FindByPathAtRevision(ctx, filePath, revision)
+		if err != nil {
+			return nil, err
+		}
+		return file.Content, nil
+	}
+}
+
+// pipelineNameAt returns the name declared by a pipeline file at a revision,
+// or an empty string if it can no longer be read.
+func (uc *processPipelineUsecase) pipelineNameAt(ctx context.Context, filePath, revision string) string {
+	file, err := uc.GitRepository.FindByPathAtRevision(ctx, filePath, revision)
+	if err != nil {
+		uc.Log.Warn("Unable to read pipeline", "filePath", filePath, "revision", revision, "error", err)
+		return ""
+	}
+
+	upd, err := parseUPD(file.Content)
+	if err != nil {
+		uc.Log.Warn("Unable to parse pipeline", "filePath", filePath, "revision", revision, "error", err)
+		return ""
+	}
+	return upd.Pipeline.Name
+}
+
+// pullRequestSummary collects what a run changed, for the pull request body.
+type pullRequestSummary struct {
+	Generated      []generatedDAG
+	Moved          []movedDAG
+	Decommissioned []decommissionedPipeline
+	Warnings       []pipelineWarning
+
+	// Failed pipelines are left out of the pull request
+	Failed []entity.FileResult
+}
+
+type pipelineWarning struct {
+	PipelinePath string
+	Message      string
+}
+
+type generatedDAG struct {
+	PipelinePath string
+	DAGPath      string
+}
+
+type movedDAG struct {
+	PreviousPipelinePath string
+	PipelinePath         string
+	PreviousDAGPath      string
+	DAGPath              string
+
+	// Pipeline names double as Airflow dag_ids
+	PreviousName string
+	Name         string
+}
+
+// DAGIDChanged reports whether the move also renamed the pipeline, which
+// Airflow treats as a brand new DAG.
+func (m movedDAG) DAGIDChanged() bool {
+	return m.PreviousName != "" && m.Name != "" && m.PreviousName != m.Name
+}
+
+type decommissionedPipeline struct {
+	Name         string
+	PipelinePath string
+	DAGPath      string
+}
+
+func (s pullRequestSummary) body() string {
+	var b strings.Builder
+	b.WriteString("This pull request was automatically generated to update DAGs based on pipeline definitions.\n")
+
+	if len(s.Generated) > 0 {
+		b.WriteString("\n### Generated DAGs\n\n")
+		for _, dag := range s.Generated {
+			fmt.Fprintf(&b, "- `%s` from `%s`\n", dag.DAGPath, dag.PipelinePath)
+		}
+	}
+
+	if len(s.Moved) > 0 {
+		b.WriteString("\n### Moved DAGs\n\n")
+		for _, dag := range s.Moved {
+			fmt.Fprintf(&b, "- `%s` → `%s` (pipeline moved from `%s` to `%s`)\n", dag.PreviousDAGPath, dag.DAGPath, dag.PreviousPipelinePath, dag.PipelinePath)
+			if dag.DAGIDChanged() {
+				fmt.Fprintf(&b, "  - :warning: `pipeline.name` changed from `%s` to `%s`. This is the Airflow `dag_id`, so Airflow will treat it as a new DAG and its run history will not carry over.\n", dag.PreviousName, dag.Name)
+			}
+		}
+	}
+
+	if len(s.Decommissioned) > 0 {
+		b.WriteString("\n### Decommissioned pipelines\n\n")
+		b.WriteString("These pipeline definitions were deleted, merging removes their DAGs from Airflow.\n\n")
+		for _, pipeline := range s.Decommissioned {
+			name := pipeline.Name
+			if name == "" {
+				name = pipeline.PipelinePath
+			}
+			fmt.Fprintf(&b, "- `%s` (`%s`), removes `%s`\n", name, pipeline.PipelinePath, pipeline.DAGPath)
+		}
+	}
+
+	if len(s.Warnings) > 0 {
+		b.WriteString("\n### Warnings\n\n")
+		for _, warning := range s.Warnings {
+			fmt.Fprintf(&b, "- :warning: `%s`: %s\n", warning.PipelinePath, warning.Message)
+		}
+	}
+
+	if len(s.Failed) > 0 {
+		b.WriteString("\n### Failed pipelines\n\n")
+		b.WriteString("No DAG changes are included for these pipeline definitions until they are fixed.\n\n")
+		for _, file := range s.Failed {
+			fmt.Fprintf(&b, "- :x: `%s`:\n\n```\n%s\n```\n", file.PipelinePath, file.Error)
+		}
+	}
+
+	return b.String()
+}
+
+// branchFor names the branch DAGs generated for a pushed commit are pushed to.
+func branchFor(after string) string {
+	if len(after) > 12 {
+		after = after[:12]
+	}
+	return BRANCH_PREFIX + after
+}
+
+func upsertPullRequest(uc *processPipelineUsecase, ctx context.Context, payload external.GitHubPushPayload, branch string, summary pullRequestSummary) (*github.PullRequest, error) {
 	owner := payload.Repository.Owner.Login
 	repoName := payload.Repository.Name
 	prTitle := "Automated DAG Generation"
-	prBody := "This pull request was automatically generated to add DAGs based on pipeline definitions."
+	prBody := summary.body()
 	baseBranch := uc.Config.Git.DefaultBranch
 	headBranch := branch
 
-	_, err := uc.GitHubService.CreatePullRequest(
+	existing, err := uc.GitHubService.FindPullRequest(owner, repoName, headBranch, baseBranch, uc.Config.Git.Token)
+	if err != nil {
+		gitCleanUp(uc, ctx, branch)
+		return nil, err
+	}
+	if existing != nil {
+		uc.Log.Info("Updating existing pull request", "pullRequest", existing.GetNumber(), "branch", branch)
+		pr, err := uc.GitHubService.UpdatePullRequest(owner, repoName, existing.GetNumber(), prTitle, prBody, uc.Config.Git.Token)
+		if err != nil {
+			gitCleanUp(uc, ctx, branch)
+			return nil, err
+		}
+		return pr, nil
+	}
+
+	pr, err := uc.GitHubService.CreatePullRequest(
 		owner,
 		repoName,
 		prTitle,
@@ -182,10 +813,10 @@ func createPullRequest(uc *processPipelineUsecase, ctx context.Context, payload
 	if err != nil {
 		// Clean up in case of error
 		gitCleanUp(uc, ctx, branch)
-		return err
+		return nil, err
 	}
 
-	return nil
+	return pr, nil
 }
 
 func gitCleanUp(uc *processPipelineUsecase, ctx context.Context, branchName string) error {
@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Suhaibshah22/pipeweaver/cmd/config"
+	"github.com/Suhaibshah22/pipeweaver/external"
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/entity"
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/port/queue"
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/port/repository"
+	"github.com/Suhaibshah22/pipeweaver/util"
+)
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		attempt int
+		initial time.Duration
+		max     time.Duration
+		backoff time.Duration // the jittered delay is between half of it and all of it
+	}{
+		{"first attempt", 1, 10 * time.Second, 10 * time.Minute, 10 * time.Second},
+		{"doubles", 2, 10 * time.Second, 10 * time.Minute, 20 * time.Second},
+		{"keeps doubling", 4, 10 * time.Second, 10 * time.Minute, 80 * time.Second},
+		{"capped", 10, 10 * time.Second, 10 * time.Minute, 10 * time.Minute},
+		{"no overflow", 200, 10 * time.Second, 10 * time.Minute, 10 * time.Minute},
+		{"initial above max", 1, time.Hour, 10 * time.Minute, 10 * time.Minute},
+		{"attempt zero", 0, 10 * time.Second, 10 * time.Minute, 10 * time.Second},
+		{"no backoff", 3, 0, 10 * time.Minute, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range 100 {
+				delay := retryDelay(tt.attempt, tt.initial, tt.max)
+				if delay < tt.backoff/2 || delay > tt.backoff {
+					t.Fatalf("retryDelay(%d, %s, %s) = %s, want between %s and %s", tt.attempt, tt.initial, tt.max, delay, tt.backoff/2, tt.backoff)
+				}
+			}
+		})
+	}
+}
+
+// settlingQueue records how runJob settles a job.
+type settlingQueue struct {
+	queue.JobQueue
+	settled string
+	reason  string
+}
+
+func (q *settlingQueue) Ack(ctx context.Context, id string, status entity.JobStatus, result *entity.JobResult) error {
+	q.settled = string(status)
+	return nil
+}
+
+func (q *settlingQueue) Retry(ctx context.Context, id string, at time.Time, reason string, result *entity.JobResult) error {
+	q.settled, q.reason = "retry", reason
+	return nil
+}
+
+func (q *settlingQueue) DeadLetter(ctx context.Context, id string, reason string, result *entity.JobResult) error {
+	q.settled, q.reason = "dead letter", reason
+	return nil
+}
+
+// pullingRepository fails every push at the first step, pulling.
+type pullingRepository struct {
+	repository.GitRepository
+	err error
+}
+
+func (r *pullingRepository) Pull(ctx context.Context) error {
+	return r.err
+}
+
+func TestRunJobSettlesJobs(t *testing.T) {
+	pushEvent, err := json.Marshal(PipelineEvent{
+		Event: external.GitHubEventPush,
+		Push:  &external.GitHubPushPayload{Ref: "refs/heads/main"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherBranch, err := json.Marshal(PipelineEvent{
+		Event: external.GitHubEventPush,
+		Push:  &external.GitHubPushPayload{Ref: "refs/heads/feature"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		defaultBranch string
+		payload       []byte
+		pullErr       error
+		attempts      int
+		want          string
+	}{
+		{"nothing to do", "main", otherBranch, nil, 1, string(entity.JobSkipped)},
+		{"push to another default branch", "feature", otherBranch, util.Retryable(errors.New("connection reset")), 1, "retry"},
+		{"push to main when it is not the default branch", "develop", pushEvent, nil, 1, string(entity.JobSkipped)},
+		{"transient failure", "main", pushEvent, util.Retryable(errors.New("connection reset")), 1, "retry"},
+		{"transient failure on the last attempt", "main", pushEvent, util.Retryable(errors.New("connection reset")), 3, "dead letter"},
+		{"permanent failure", "main", pushEvent, errors.New("authentication required"), 1, "dead letter"},
+		{"unreadable payload", "main", []byte("{"), nil, 1, "dead letter"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Git.DefaultBranch = tt.defaultBranch
+			cfg.Queue.MaxAttempts = 3
+			cfg.Queue.InitialBackoff = time.Second
+			cfg.Queue.MaxBackoff = time.Minute
+
+			jobQueue := &settlingQueue{}
+			uc := &processPipelineUsecase{
+				GitRepository: &pullingRepository{err: tt.pullErr},
+				JobQueue:      jobQueue,
+				Config:        cfg,
+				Log:           slog.New(slog.NewTextHandler(io.Discard, nil)),
+			}
+
+			uc.runJob(context.Background(), &entity.Job{ID: "job", Payload: tt.payload, Attempts: tt.attempts})
+			if jobQueue.settled != tt.want {
+				t.Errorf("job was settled as %q, want %q", jobQueue.settled, tt.want)
+			}
+			if tt.pullErr != nil && jobQueue.reason != tt.pullErr.Error() {
+				t.Errorf("job failed with %q, want %q", jobQueue.reason, tt.pullErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/validation"
+	"github.com/Suhaibshah22/pipeweaver/internal/usecase/templates"
+)
+
+// defaultStartDate is the start_date of pipelines that do not set one.
+var defaultStartDate = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// DAGSchedule is when a DAG runs, ready to be rendered as arguments of its DAG.
+type DAGSchedule struct {
+	Expression    templates.Python // a cron or preset string, timedelta(...), [Dataset(...)] or None
+	StartDate     templates.Python
+	EndDate       templates.Python // empty when the DAG runs indefinitely
+	Catchup       bool
+	MaxActiveRuns int // 0 leaves Airflow's default, @continuous schedules always have 1
+
+	// Imports are of datetime, timedelta and pendulum, which the schedule
+	// uses. The Dataset import is with the Airflow imports, see Imports
+	Imports []templates.Python
+
+	datasets bool
+}
+
+// ScheduleInterval returns the cron or preset expression of the schedule, for
+// templates written before DAGs had a Schedule.
+func (d DAGTemplateData) ScheduleInterval() (templates.Python, error) {
+	switch scheduleType := validation.ScheduleType(d.Pipeline.Schedule); scheduleType {
+	case "":
+		return "None", nil
+	case "cron", "preset":
+		return templates.Quote(d.Pipeline.Schedule.Expression), nil
+	default:
+		return "", fmt.Errorf("pipeline.schedule: %s schedules need DAG template 2.0 or later", scheduleType)
+	}
+}
+
+// Schedule returns when the DAG runs. Without a time zone dates are naive
+// datetimes in Airflow's default time zone, with one they are pendulum
+// datetimes in it, which cron schedules then follow.
+func (d DAGTemplateData) Schedule() (*DAGSchedule, error) {
+	schedule := &DAGSchedule{Expression: "None"}
+	s := d.Pipeline.Schedule
+	if s == nil {
+		schedule.StartDate, schedule.Imports = scheduleDate(defaultStartDate, "")
+		return schedule, nil
+	}
+
+	// 1. What triggers a run
+	switch scheduleType := validation.ScheduleType(s); scheduleType {
+	case "":
+	case "cron", "preset":
+		schedule.Expression = templates.Quote(s.Expression)
+	case "timedelta":
+		interval, err := validation.ParseTimedelta(s.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("pipeline.schedule.expression: %w", err)
+		}
+		schedule.Expression = timedeltaCall(interval)
+		schedule.Imports = append(schedule.Imports, "from datetime import timedelta")
+	case "dataset":
+		datasets := make([]string, len(s.Datasets))
+		for i, uri := range s.Datasets {
+			datasets[i] = "Dataset(" + string(templates.Quote(uri)) + ")"
+		}
+		schedule.Expression = templates.Python("[" + strings.Join(datasets, ", ") + "]")
+		schedule.datasets = true
+	default:
+		return nil, fmt.Errorf("pipeline.schedule.type: unknown schedule type %q", scheduleType)
+	}
+
+	// 2. The dates it runs between
+	start := defaultStartDate
+	if s.StartDate != "" {
+		var err error
+		if start, err = validation.ParseScheduleDate(s.StartDate); err != nil {
+			return nil, fmt.Errorf("pipeline.schedule.start_date: %w", err)
+		}
+	}
+	var imports []templates.Python
+	schedule.StartDate, imports = scheduleDate(start, s.Timezone)
+	if s.EndDate != "" {
+		end, err := validation.ParseScheduleDate(s.EndDate)
+		if err != nil {
+			return nil, fmt.Errorf("pipeline.schedule.end_date: %w", err)
+		}
+		schedule.EndDate, _ = scheduleDate(end, s.Timezone)
+	}
+	schedule.Imports = append(schedule.Imports, imports...)
+
+	// 3. How runs are run
+	schedule.Catchup = s.Catchup != nil && *s.Catchup
+	schedule.MaxActiveRuns = s.MaxActiveRuns
+	if s.Expression == "@continuous" {
+		// Airflow refuses to load a continuous DAG with more than one
+		// active run, which is its default when left out
+		schedule.MaxActiveRuns = 1
+	}
+	return schedule, nil
+}
+
+// scheduleDate renders a date of the schedule and the import it needs.
+func scheduleDate(t time.Time, timezone string) (templates.Python, []templates.Python) {
+	args := []string{fmt.Sprint(t.Year()), fmt.Sprint(int(t.Month())), fmt.Sprint(t.Day())}
+	switch {
+	case t.Second() != 0:
+		args = append(args, fmt.Sprint(t.Hour()), fmt.Sprint(t.Minute()), fmt.Sprint(t.Second()))
+	case t.Hour() != 0 || t.Minute() != 0:
+		args = append(args, fmt.Sprint(t.Hour()), fmt.Sprint(t.Minute()))
+	}
+
+	if timezone == "" {
+		return templates.Python("datetime(" + strings.Join(args, ", ") + ")"), []templates.Python{"from datetime import datetime"}
+	}
+	args = append(args, "tz="+string(templates.Quote(timezone)))
+	return templates.Python("pendulum.datetime(" + strings.Join(args, ", ") + ")"), []templates.Python{"import pendulum"}
+}
+
+func timedeltaCall(interval validation.Timedelta) templates.Python {
+	var args []string
+	for _, part := range []struct {
+		name  string
+		value int
+	}{{"days", interval.Days}, {"hours", interval.Hours}, {"minutes", interval.Minutes}, {"seconds", interval.Seconds}} {
+		if part.value != 0 {
+			args = append(args, fmt.Sprintf("%s=%d", part.name, part.value))
+		}
+	}
+	return templates.Python("timedelta(" + strings.Join(args, ", ") + ")")
+}
@@ -0,0 +1,298 @@
+package templates
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// Python is Python source that is rendered into DAG code as is. Values
+// produced by pyStr, toPython and toPythonDict are Python, any other value is
+// escaped for where it is rendered. Inside strings and comments Python is
+// text like any other value and is escaped too.
+type Python string
+
+// rawPython is a value opted out of escaping with raw, the only value
+// rendered as is inside strings and comments.
+type rawPython string
+
+// Parse parses a DAG template with the function library and escapes every
+// value it renders, so pipeline definitions can never produce invalid Python
+// or inject code into a DAG:
+//
+//	dag_id={{.PipelineName}}            dag_id="orders"
+//	retries={{.Pipeline.Parameters.n}}  retries=3
+//	print("Reading {{.Name}}")          print("Reading \"quoted\" name")
+//	# Owner: {{.Name}}                  # Owner: first line\nsecond line
+//
+// Outside of strings a value becomes a Python literal, inside strings, comments
+// and docstrings it is escaped for the surrounding quotes, even when it is
+// already Python such as {{pyStr .Name}}. Only {{raw .Value}} opts out of
+// escaping.
+func Parse(t *Template) (*template.Template, error) {
+	tmpl, err := template.New(t.Name).Funcs(Funcs()).Funcs(escapeFuncs).Parse(string(t.Content))
+	if err != nil {
+		return nil, fmt.Errorf("parse template error: %w", err)
+	}
+
+	for _, defined := range tmpl.Templates() {
+		if defined.Tree == nil || defined.Tree.Root == nil {
+			continue
+		}
+		e := &escaper{escapers: map[*parse.ActionNode]string{}}
+		end, err := e.list(defined.Tree.Root, pyContext{})
+		if err == nil && end.state == pyString {
+			err = fmt.Errorf("ends inside of a Python %s", end)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("escape template error: %s: %w", defined.Name(), err)
+		}
+		e.apply()
+	}
+	return tmpl, nil
+}
+
+// pyContext is where in a Python file rendering has got to.
+type pyContext struct {
+	state  pyState
+	quote  byte // of the string being rendered
+	triple bool
+	fmt    bool // an f-string, braces need escaping
+}
+
+type pyState int
+
+const (
+	pyCode pyState = iota
+	pyString
+	pyComment
+
+	// pyCodeOrComment follows a branch that can end in a comment or in code,
+	// both end with the line.
+	pyCodeOrComment
+)
+
+func (c pyContext) String() string {
+	switch c.state {
+	case pyString:
+		return "string"
+	case pyComment:
+		return "comment"
+	case pyCodeOrComment:
+		return "comment or code"
+	}
+	return "code"
+}
+
+// escaper picks the escaping function of every action that renders a value.
+type escaper struct {
+	escapers map[*parse.ActionNode]string
+}
+
+// list walks the nodes of list starting in context c, returning the context
+// rendering ends in.
+func (e *escaper) list(list *parse.ListNode, c pyContext) (pyContext, error) {
+	if list == nil {
+		return c, nil
+	}
+
+	var err error
+	for _, node := range list.Nodes {
+		c, err = e.node(node, c)
+		if err != nil {
+			return c, err
+		}
+	}
+	return c, nil
+}
+
+func (e *escaper) node(node parse.Node, c pyContext) (pyContext, error) {
+	switch n := node.(type) {
+	case *parse.TextNode:
+		return scanPython(n.Text, c), nil
+	case *parse.ActionNode:
+		// Assignments like {{$name := .Name}} render nothing
+		if len(n.Pipe.Decl) > 0 {
+			return c, nil
+		}
+		if c.state == pyCodeOrComment {
+			return c, fmt.Errorf("%s may be rendered inside of Python code or a comment, start a new line before it", n)
+		}
+		name := escapeFunc(c)
+		if previous, seen := e.escapers[n]; seen && previous != name {
+			return c, fmt.Errorf("%s is rendered both inside of Python code and inside of a string or comment", n)
+		}
+		e.escapers[n] = name
+		return c, nil
+	case *parse.IfNode:
+		return e.branches(&n.BranchNode, c, "if")
+	case *parse.WithNode:
+		return e.branches(&n.BranchNode, c, "with")
+	case *parse.RangeNode:
+		// Later iterations start where the previous one ended
+		end, err := e.list(n.List, c)
+		if err != nil {
+			return c, err
+		}
+		if end != c {
+			again, err := e.list(n.List, end)
+			if err != nil {
+				return c, err
+			}
+			if again != end {
+				return c, fmt.Errorf("{{range}} body starts in Python %s but ends in Python %s", c, end)
+			}
+		}
+		if n.ElseList == nil {
+			return e.join(end, c, "range")
+		}
+		otherwise, err := e.list(n.ElseList, c)
+		if err != nil {
+			return c, err
+		}
+		return e.join(end, otherwise, "range")
+	case *parse.TemplateNode:
+		if c != (pyContext{}) {
+			return c, fmt.Errorf("{{template %q}} is called inside of a Python %s, templates can only be called from code", n.Name, c)
+		}
+		return c, nil
+	}
+	return c, nil
+}
+
+// branches escapes both branches of an {{if}} or {{with}}, which must leave
+// rendering in the same context for what follows to be escaped correctly.
+func (e *escaper) branches(n *parse.BranchNode, c pyContext, keyword string) (pyContext, error) {
+	then, err := e.list(n.List, c)
+	if err != nil {
+		return c, err
+	}
+	otherwise, err := e.list(n.ElseList, c)
+	if err != nil {
+		return c, err
+	}
+	return e.join(then, otherwise, keyword)
+}
+
+// join is the context after either of two ways through the template, which
+// must agree for what follows to be escaped correctly.
+func (e *escaper) join(a, b pyContext, keyword string) (pyContext, error) {
+	if a == b {
+		return a, nil
+	}
+
+	// Code and comments only differ until the end of the line
+	lineEnded := func(c pyContext) bool {
+		return c.state == pyCode || c.state == pyComment || c.state == pyCodeOrComment
+	}
+	if lineEnded(a) && lineEnded(b) {
+		return pyContext{state: pyCodeOrComment}, nil
+	}
+	return a, fmt.Errorf("{{%s}} can end in different Python contexts, %s and %s", keyword, a, b)
+}
+
+// apply appends the escaping function picked for every action to its pipeline.
+func (e *escaper) apply() {
+	for action, name := range e.escapers {
+		pos := action.Position()
+		action.Pipe.Cmds = append(action.Pipe.Cmds, &parse.CommandNode{
+			NodeType: parse.NodeCommand,
+			Pos:      pos,
+			Args:     []parse.Node{parse.NewIdentifier(name).SetPos(pos)},
+		})
+	}
+}
+
+// escapeFunc is the escaping function of a value rendered in context c.
+func escapeFunc(c pyContext) string {
+	switch {
+	case c.state == pyString && c.fmt:
+		return "_pyFStringContent"
+	case c.state != pyCode:
+		return "_pyStringContent"
+	}
+	return "_pyLiteral"
+}
+
+// scanPython advances the context over literal Python text.
+func scanPython(text []byte, c pyContext) pyContext {
+	for i := 0; i < len(text); i++ {
+		ch := text[i]
+		switch c.state {
+		case pyComment, pyCodeOrComment:
+			if ch == '\n' {
+				c = pyContext{}
+			}
+		case pyString:
+			switch {
+			case ch == '\\':
+				i++
+			case ch == '\n' && !c.triple:
+				// Unterminated, Python rejects it anyway
+				c = pyContext{}
+			case ch == c.quote && !c.triple:
+				c = pyContext{}
+			case ch == c.quote && i+2 < len(text) && text[i+1] == ch && text[i+2] == ch:
+				i += 2
+				c = pyContext{}
+			}
+		default:
+			switch ch {
+			case '#':
+				c = pyContext{state: pyComment}
+			case '"', '\'':
+				c = pyContext{state: pyString, quote: ch, fmt: stringPrefix(text[:i], 'f')}
+				if i+2 < len(text) && text[i+1] == ch && text[i+2] == ch {
+					c.triple = true
+					i += 2
+				}
+			}
+		}
+	}
+	return c
+}
+
+// stringPrefix reports whether the string starting after text has a prefix
+// such as f or rb containing letter.
+func stringPrefix(text []byte, letter byte) bool {
+	start := len(text)
+	for start > 0 && len(text)-start < 2 && strings.IndexByte("rRbBuUfF", text[start-1]) >= 0 {
+		start--
+	}
+	if start > 0 && isIdentifierByte(text[start-1]) {
+		// Part of a longer name, e.g. elif"
+		return false
+	}
+	prefix := strings.ToLower(string(text[start:]))
+	return strings.IndexByte(prefix, letter) >= 0
+}
+
+func isIdentifierByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// escapeFuncs are appended to actions by the escaper.
+var escapeFuncs = template.FuncMap{
+	"_pyLiteral": func(value any) (Python, error) {
+		switch v := value.(type) {
+		case Python:
+			return v, nil
+		case rawPython:
+			return Python(v), nil
+		}
+		return toPython(value)
+	},
+	"_pyStringContent": func(value any) Python {
+		if r, ok := value.(rawPython); ok {
+			return Python(r)
+		}
+		return Python(escapeString(fmt.Sprint(value), `"'`, false))
+	},
+	"_pyFStringContent": func(value any) Python {
+		if r, ok := value.(rawPython); ok {
+			return Python(r)
+		}
+		return Python(escapeString(fmt.Sprint(value), `"'`, true))
+	},
+}
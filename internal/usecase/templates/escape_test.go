@@ -0,0 +1,230 @@
+package templates_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/Suhaibshah22/pipeweaver/cmd/config"
+	validatorAdapter "github.com/Suhaibshah22/pipeweaver/internal/adapter/validator"
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/entity"
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/validation"
+	"github.com/Suhaibshah22/pipeweaver/internal/usecase"
+	"github.com/Suhaibshah22/pipeweaver/internal/usecase/templates"
+
+	"gopkg.in/yaml.v3"
+)
+
+// airflowID is what names must look like to get past validation.
+var airflowID = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// FuzzRender renders pipelines with arbitrary text in every field that reaches
+// a DAG through both built-in templates, which must always produce valid
+// Python or reject the definition.
+func FuzzRender(f *testing.F) {
+	for _, seed := range []string{
+		"plain",
+		`double "quoted"`,
+		`single 'quoted'`,
+		`"""triple""" and '''triple'''`,
+		`trailing backslash \`,
+		`\" escaped quote`,
+		"first line\nsecond line\r\n\ttabbed",
+		"f-string {braces} and {{doubled}} }{",
+		"{{ ds }} {% if x %}",
+		"# not a comment",
+		"café ✓ 数据 ​ \U0001F600",
+		"\x00\x01\x7f",
+		"```\nfenced\n```",
+		"'; import os; os.system('id') #",
+	} {
+		f.Add(seed, seed, seed, seed, seed, seed)
+	}
+
+	registry := mustTemplates(f)
+	generator := usecase.NewGenerateAirFlowDAGUsecase(registry, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), &config.Config{})
+	syntax := validatorAdapter.NewPythonSyntaxValidator()
+	python := startPython(f)
+
+	f.Fuzz(func(t *testing.T, name, description, query, domain, owner, channel string) {
+		for _, version := range []string{"v1.0", "2.0.0"} {
+			content := fuzzPipeline(t, version, name, description, query, domain, owner, channel)
+
+			dag, err := generator.Execute(context.Background(), content, "pipelines/fuzz.yaml", nil)
+			if err != nil {
+				var problems validation.Errors
+				if !errors.As(err, &problems) {
+					t.Fatalf("version %s: rendering failed without a validation problem: %v\n%s", version, err, content)
+				}
+				continue
+			}
+
+			if err := syntax.Validate(context.Background(), "fuzz.py", dag.Content); err != nil {
+				t.Fatalf("version %s: %v\n%s", version, err, dag.Content)
+			}
+			if python != nil {
+				if err := python.compile(dag.Content); err != nil {
+					t.Fatalf("version %s: python3: %v\n%s", version, err, dag.Content)
+				}
+			}
+		}
+	})
+}
+
+func mustTemplates(f *testing.F) *templates.Registry {
+	fsys, err := templates.New("")
+	if err != nil {
+		f.Fatal(err)
+	}
+	return templates.NewRegistry(fsys)
+}
+
+// fuzzPipeline puts the fuzzed values in every field rendered into DAGs. Names
+// that validation would reject are only used where any text is allowed.
+func fuzzPipeline(t *testing.T, version, name, description, query, domain, owner, channel string) []byte {
+	pipelineName, stepName := "fuzz", "transform"
+	if airflowID.MatchString(name) {
+		pipelineName, stepName = name, name
+	}
+
+	notifications := &entity.Notifications{
+		OnSuccess: []entity.NotificationTarget{{Method: "slack", Channel: channel, ConnID: owner}},
+		OnFailure: []entity.NotificationTarget{
+			{Method: "email", Recipients: []string{owner, channel}},
+			{Method: "slack_webhook", ConnID: channel},
+		},
+	}
+	upd := entity.UnifiedPipelineDefinition{Pipeline: entity.Pipeline{
+		Name:          pipelineName,
+		Version:       version,
+		Domain:        domain,
+		Description:   description,
+		Owners:        []entity.Owner{{Name: owner, Email: channel}},
+		Notifications: notifications,
+		Steps: []entity.Step{
+			{
+				Name:                stepName,
+				Type:                "transformation",
+				Description:         description,
+				TransformationQuery: query,
+				Outputs:             []entity.DataRef{{Name: "warehouse", Type: "snowflake", TableName: domain}},
+				Notifications:       notifications,
+			},
+			{
+				Name:        "notify",
+				Type:        "bash",
+				Description: name,
+				DependsOn:   []string{stepName},
+				Config:      map[string]any{"bash_command": query, "env": map[string]any{name: description}},
+			},
+		},
+	}}
+
+	content, err := yaml.Marshal(upd)
+	if err != nil {
+		t.Skipf("not representable in YAML: %v", err)
+	}
+	return content
+}
+
+// compileScript compiles every source sent to it like py_compile does,
+// answering ok or the syntax error. Sources are preceded by their length.
+const compileScript = `
+import sys
+for header in sys.stdin.buffer:
+    source = sys.stdin.buffer.read(int(header))
+    try:
+        compile(source, "dag.py", "exec")
+        print("ok", flush=True)
+    except (SyntaxError, ValueError) as e:
+        print(repr(e), flush=True)
+`
+
+// python compiles DAGs with one long running python3, starting one per DAG
+// would be most of the time spent fuzzing.
+type python struct {
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+// startPython returns nil when python3 is not installed.
+func startPython(f *testing.F) *python {
+	path, err := exec.LookPath("python3")
+	if err != nil {
+		return nil
+	}
+
+	cmd := exec.Command(path, "-c", compileScript)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		f.Fatal(err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		f.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		f.Fatal(err)
+	}
+	f.Cleanup(func() {
+		stdin.Close()
+		cmd.Wait()
+	})
+	return &python{stdin: stdin, stdout: bufio.NewReader(stdout)}
+}
+
+func (p *python) compile(content []byte) error {
+	if _, err := fmt.Fprintf(p.stdin, "%d\n%s", len(content), content); err != nil {
+		return err
+	}
+	answer, err := p.stdout.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if answer = strings.TrimSpace(answer); answer != "ok" {
+		return errors.New(answer)
+	}
+	return nil
+}
+
+// Values that are already Python, such as those of pyStr and toPython, are
+// still text inside strings and comments, only raw is rendered as is.
+func TestParseEscapesPythonInStringsAndComments(t *testing.T) {
+	const injection = `+__import__('os').system('id')+`
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"pyStr in a string", `print("{{pyStr .}}")`, `print("\"+__import__(\'os\').system(\'id\')+\"")`},
+		{"toPython in a string", `print('{{toPython .}}')`, `print('\"+__import__(\'os\').system(\'id\')+\"')`},
+		{"pyStr in an f-string", `print(f"{{pyStr .}} {x}")`, `print(f"\"+__import__(\'os\').system(\'id\')+\" {x}")`},
+		{"indented pyStr in a docstring", "\"\"\"\n{{pyStr . | indent 2}}\n\"\"\"", "\"\"\"\n  \\\"+__import__(\\'os\\').system(\\'id\\')+\\\"\n\"\"\""},
+		{"pyStr in a comment", "# {{pyStr .}}\nx = 1", "# \\\"+__import__(\\'os\\').system(\\'id\\')+\\\"\nx = 1"},
+		{"pyStr in code", `print({{pyStr .}})`, `print("+__import__('os').system('id')+")`},
+		{"raw in a string", `print("{{raw .}}")`, `print("+__import__('os').system('id')+")`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := templates.Parse(&templates.Template{Name: "test", Content: []byte(tt.template)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b strings.Builder
+			if err := tmpl.Execute(&b, injection); err != nil {
+				t.Fatal(err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("rendered %s\ngot:  %s\nwant: %s", tt.template, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package templates
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/schema"
+)
+
+// Funcs are the functions available to DAG templates, on top of the text/template builtins.
+//
+//	pyStr        a Python string literal, quoted and escaped: {{pyStr .Pipeline.Description}}
+//	toPython     a Python literal of any value: None, True, 3, "a", [...], {...}
+//	toPythonDict a Python dict literal of a map or struct, keyed like the pipeline YAML
+//	toJSON       a JSON document of any value
+//	snake_case   "Load Customers" -> load_customers
+//	indent       prefixes every line with n spaces: {{toPythonDict .Step.Config | indent 4}}
+//	default      a fallback for empty values: {{.Pipeline.Domain | default "unknown"}}
+//	required     fails rendering when a value is empty: {{required "domain is required" .Pipeline.Domain}}
+//	raw          renders a value as is, without escaping it
+//
+// pyStr, toPython and toPythonDict return Python, which is not escaped again
+// in code. Only raw is rendered as is inside strings and comments, see Parse.
+func Funcs() template.FuncMap {
+	return template.FuncMap{
+		"pyStr":        pyStr,
+		"toPython":     toPython,
+		"toPythonDict": toPythonDict,
+		"toJSON":       toJSON,
+		"snake_case":   snakeCase,
+		"indent":       indent,
+		"default":      defaultValue,
+		"required":     required,
+		"raw":          raw,
+	}
+}
+
+// Quote returns s as a Python string literal.
+func Quote(s string) Python {
+	return pyStr(s)
+}
+
+// Literal returns value as a Python literal, see toPython.
+func Literal(value any) (Python, error) {
+	return toPython(value)
+}
+
+// pyStr quotes a value as a Python string literal. Anything that is not a
+// string is formatted with fmt first.
+func pyStr(value any) Python {
+	s, ok := value.(string)
+	if !ok {
+		s = fmt.Sprint(value)
+	}
+	return Python(`"` + escapeString(s, `"`, false) + `"`)
+}
+
+// escapeString escapes s for use inside a Python string literal delimited by
+// any of quotes. Braces are doubled for f-strings when braces is set.
+func escapeString(s, quotes string, braces bool) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r < utf8.RuneSelf && strings.ContainsRune(quotes, r):
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case braces && (r == '{' || r == '}'):
+			b.WriteRune(r)
+			b.WriteRune(r)
+		case unicode.IsPrint(r):
+			b.WriteRune(r)
+		case r <= 0xff:
+			fmt.Fprintf(&b, `\x%02x`, r)
+		case r <= 0xffff:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			fmt.Fprintf(&b, `\U%08x`, r)
+		}
+	}
+	return b.String()
+}
+
+// toPython renders a value as a Python literal. Map keys are sorted so the
+// generated DAG does not change between runs.
+func toPython(value any) (Python, error) {
+	literal, err := pythonLiteral(reflect.ValueOf(value))
+	return Python(literal), err
+}
+
+func toPythonDict(value any) (Python, error) {
+	v := indirect(reflect.ValueOf(value))
+	if !v.IsValid() {
+		return "{}", nil
+	}
+	if v.Kind() != reflect.Map && v.Kind() != reflect.Struct {
+		return "", fmt.Errorf("toPythonDict: expected a map or struct, got %s", v.Kind())
+	}
+	literal, err := pythonLiteral(v)
+	return Python(literal), err
+}
+
+func pythonLiteral(v reflect.Value) (string, error) {
+	if v.IsValid() && v.CanInterface() {
+		if p, ok := v.Interface().(Python); ok {
+			return string(p), nil
+		}
+	}
+	v = indirect(v)
+	if !v.IsValid() {
+		return "None", nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return "True", nil
+		}
+		return "False", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		switch {
+		case math.IsNaN(f):
+			return `float("nan")`, nil
+		case math.IsInf(f, 1):
+			return `float("inf")`, nil
+		case math.IsInf(f, -1):
+			return `float("-inf")`, nil
+		}
+		s := strconv.FormatFloat(f, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eE") {
+			s += ".0"
+		}
+		return s, nil
+	case reflect.String:
+		return string(pyStr(v.String())), nil
+	case reflect.Slice, reflect.Array:
+		items := make([]string, v.Len())
+		for i := range items {
+			item, err := pythonLiteral(v.Index(i))
+			if err != nil {
+				return "", err
+			}
+			items[i] = item
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	case reflect.Map:
+		type entry struct{ key, value string }
+		entries := make([]entry, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key, err := pythonLiteral(iter.Key())
+			if err != nil {
+				return "", err
+			}
+			value, err := pythonLiteral(iter.Value())
+			if err != nil {
+				return "", err
+			}
+			entries = append(entries, entry{key, value})
+		}
+		slices.SortFunc(entries, func(a, b entry) int { return strings.Compare(a.key, b.key) })
+
+		items := make([]string, len(entries))
+		for i, e := range entries {
+			items[i] = e.key + ": " + e.value
+		}
+		return "{" + strings.Join(items, ", ") + "}", nil
+	case reflect.Struct:
+		// Keyed by the YAML field names, as the pipeline author wrote them
+		var items []string
+		for i := 0; i < v.NumField(); i++ {
+			name := schema.FieldName(v.Type().Field(i))
+			if name == "" {
+				continue
+			}
+			value, err := pythonLiteral(v.Field(i))
+			if err != nil {
+				return "", err
+			}
+			items = append(items, string(pyStr(name))+": "+value)
+		}
+		return "{" + strings.Join(items, ", ") + "}", nil
+	}
+	return "", fmt.Errorf("cannot render %s as a Python literal", v.Type())
+}
+
+// indirect follows pointers and interfaces to the value they hold.
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func toJSON(value any) (string, error) {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("toJSON: %w", err)
+	}
+	return string(content), nil
+}
+
+// snakeCase turns a name into a lower case Python identifier, e.g.
+// "Load Customers" or "loadCustomers" into load_customers.
+func snakeCase(s string) string {
+	var b strings.Builder
+	var previous rune
+	for i, r := range s {
+		switch {
+		case unicode.IsUpper(r):
+			if i > 0 && (unicode.IsLower(previous) || unicode.IsDigit(previous)) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		default:
+			r = '_'
+			if previous != '_' && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+		}
+		previous = r
+	}
+
+	name := strings.TrimRight(b.String(), "_")
+	if name != "" && unicode.IsDigit(rune(name[0])) {
+		name = "_" + name
+	}
+	return name
+}
+
+// indent prefixes every line of a value with n spaces. Python stays Python, so
+// {{toPython .Value | indent 4}} is not escaped again in code, and raw stays raw.
+func indent(n int, value any) any {
+	padding := strings.Repeat(" ", n)
+	switch v := value.(type) {
+	case Python:
+		return Python(padding + strings.ReplaceAll(string(v), "\n", "\n"+padding))
+	case rawPython:
+		return rawPython(padding + strings.ReplaceAll(string(v), "\n", "\n"+padding))
+	}
+	return padding + strings.ReplaceAll(fmt.Sprint(value), "\n", "\n"+padding)
+}
+
+// raw renders a value as is, opting out of escaping. Only use it for values
+// that are meant to be Python source.
+func raw(value any) rawPython {
+	if p, ok := value.(Python); ok {
+		return rawPython(p)
+	}
+	return rawPython(fmt.Sprint(value))
+}
+
+// defaultValue returns value, or fallback when value is empty. Arguments are
+// in that order so it can end a pipeline: {{.Pipeline.Domain | default "unknown"}}
+func defaultValue(fallback, value any) any {
+	if empty(value) {
+		return fallback
+	}
+	return value
+}
+
+// required fails rendering with message when value is empty.
+func required(message string, value any) (any, error) {
+	if empty(value) {
+		return nil, errors.New(message)
+	}
+	return value, nil
+}
+
+func empty(value any) bool {
+	v := indirect(reflect.ValueOf(value))
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
@@ -0,0 +1,124 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Suhaibshah22/pipeweaver/internal/domain/semver"
+)
+
+// FilePrefix names DAG templates, followed by the version they render, e.g.
+// dag_template.py.tmpl.v1.0.
+const FilePrefix = "dag_template.py.tmpl."
+
+var ErrNoTemplate = errors.New("no DAG template")
+
+// deprecatedComment marks a template deprecated when it opens the template,
+// e.g. {{- /* deprecated: use v2.0, which ... */ -}}
+var deprecatedComment = regexp.MustCompile(`^\{\{-?\s*/\*\s*deprecated:\s*((?s).*?)\s*\*/\s*-?\}\}`)
+
+// Template is a DAG template resolved from the version a pipeline asks for.
+type Template struct {
+	Version string // as in the file name, e.g. v1.0
+	Name    string
+	Content []byte
+
+	// Deprecated explains why the template should no longer be used, it is
+	// empty unless the template is deprecated.
+	Deprecated string
+}
+
+// Registry resolves pipeline versions to the templates available in a
+// filesystem. The filesystem is listed on every lookup, so templates added to
+// an override directory are picked up without a restart.
+type Registry struct {
+	fsys fs.FS
+}
+
+func NewRegistry(fsys fs.FS) *Registry {
+	return &Registry{fsys: fsys}
+}
+
+type available struct {
+	version semver.Version
+	spelled string
+	name    string
+}
+
+// Resolve returns the highest template version matching the requested one,
+// see semver.Resolve.
+func (r *Registry) Resolve(requested string) (*Template, error) {
+	templates, err := r.list()
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]string, len(templates))
+	for i, t := range templates {
+		versions[i] = t.spelled
+	}
+	resolved, err := semver.Resolve(requested, versions)
+	if errors.Is(err, semver.ErrNoMatch) {
+		return nil, fmt.Errorf("%w matches version %q, available versions: %s", ErrNoTemplate, requested, strings.Join(versions, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+	best := templates[slices.IndexFunc(templates, func(t available) bool { return t.spelled == resolved })]
+
+	content, err := fs.ReadFile(r.fsys, best.name)
+	if err != nil {
+		return nil, fmt.Errorf("read template error: %w", err)
+	}
+
+	template := &Template{Version: best.spelled, Name: best.name, Content: content}
+	if match := deprecatedComment.FindSubmatch(content); match != nil {
+		template.Deprecated = string(match[1])
+		if template.Deprecated == "" {
+			template.Deprecated = "no reason given"
+		}
+	}
+	return template, nil
+}
+
+// Versions lists the available template versions, lowest first.
+func (r *Registry) Versions() ([]string, error) {
+	templates, err := r.list()
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]string, len(templates))
+	for i, t := range templates {
+		versions[i] = t.spelled
+	}
+	return versions, nil
+}
+
+// list returns the templates whose file names carry a valid version, lowest first.
+func (r *Registry) list() ([]available, error) {
+	entries, err := fs.ReadDir(r.fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+
+	var templates []available
+	for _, entry := range entries {
+		spelled, ok := strings.CutPrefix(entry.Name(), FilePrefix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		v, err := semver.Parse(spelled)
+		if err != nil {
+			continue
+		}
+		templates = append(templates, available{version: v, spelled: spelled, name: entry.Name()})
+	}
+
+	slices.SortStableFunc(templates, func(a, b available) int { return a.version.Compare(b.version) })
+	return templates, nil
+}
@@ -0,0 +1,69 @@
+// Package templates holds the DAG templates built into the binary, and layers
+// a directory of custom templates on top of them.
+package templates
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+)
+
+//go:embed dag_template.py.tmpl.*
+var builtin embed.FS
+
+// New returns the built-in templates, overridden by the templates in
+// overrideDir. A template in overrideDir replaces the built-in template of the
+// same name, and can add templates for versions that are not built in. With an
+// empty overrideDir only the built-in templates are used.
+func New(overrideDir string) (fs.FS, error) {
+	if overrideDir == "" {
+		return builtin, nil
+	}
+
+	info, err := os.Stat(overrideDir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, &fs.PathError{Op: "open", Path: overrideDir, Err: errors.New("not a directory")}
+	}
+
+	return &layered{upper: os.DirFS(overrideDir), lower: builtin}, nil
+}
+
+// layered looks up files in upper first and falls back to lower.
+type layered struct {
+	upper fs.FS
+	lower fs.FS
+}
+
+func (l *layered) Open(name string) (fs.File, error) {
+	file, err := l.upper.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return l.lower.Open(name)
+	}
+	return file, err
+}
+
+// ReadDir lists the entries of both layers, the upper one winning for names
+// present in both.
+func (l *layered) ReadDir(name string) ([]fs.DirEntry, error) {
+	upper, upperErr := fs.ReadDir(l.upper, name)
+	lower, lowerErr := fs.ReadDir(l.lower, name)
+	if upperErr != nil && lowerErr != nil {
+		return nil, upperErr
+	}
+
+	entries := upper
+	for _, entry := range lower {
+		overridden := slices.ContainsFunc(upper, func(e fs.DirEntry) bool { return e.Name() == entry.Name() })
+		if !overridden {
+			entries = append(entries, entry)
+		}
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return entries, nil
+}
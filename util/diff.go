@@ -0,0 +1,98 @@
+package util
+
+import (
+	"bytes"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// UnifiedDiff renders a git style unified diff between two versions of a file.
+// A nil from or to content marks the file as created or deleted respectively.
+// An empty string is returned when both versions are identical.
+func UnifiedDiff(fromPath string, from []byte, toPath string, to []byte) (string, error) {
+	if from != nil && to != nil && fromPath == toPath && bytes.Equal(from, to) {
+		return "", nil
+	}
+
+	fp := &filePatch{}
+	if from != nil {
+		fp.from = newDiffFile(fromPath, from)
+	}
+	if to != nil {
+		fp.to = newDiffFile(toPath, to)
+	}
+
+	for _, d := range diff.Do(string(from), string(to)) {
+		var op fdiff.Operation
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			op = fdiff.Add
+		case diffmatchpatch.DiffDelete:
+			op = fdiff.Delete
+		default:
+			op = fdiff.Equal
+		}
+		fp.chunks = append(fp.chunks, &chunk{content: d.Text, op: op})
+	}
+
+	var out bytes.Buffer
+	encoder := fdiff.NewUnifiedEncoder(&out, fdiff.DefaultContextLines)
+	if err := encoder.Encode(&patch{filePatches: []fdiff.FilePatch{fp}}); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// The types below adapt in-memory contents to go-git's diff.Patch interfaces
+// so its unified encoder can be reused.
+
+type patch struct {
+	filePatches []fdiff.FilePatch
+}
+
+func (p *patch) FilePatches() []fdiff.FilePatch { return p.filePatches }
+func (p *patch) Message() string                { return "" }
+
+type filePatch struct {
+	from, to *diffFile
+	chunks   []fdiff.Chunk
+}
+
+func (fp *filePatch) IsBinary() bool        { return false }
+func (fp *filePatch) Chunks() []fdiff.Chunk { return fp.chunks }
+func (fp *filePatch) Files() (fdiff.File, fdiff.File) {
+	// Return untyped nils so the encoder can detect created and deleted files.
+	var from, to fdiff.File
+	if fp.from != nil {
+		from = fp.from
+	}
+	if fp.to != nil {
+		to = fp.to
+	}
+	return from, to
+}
+
+type diffFile struct {
+	path string
+	hash plumbing.Hash
+}
+
+func newDiffFile(path string, content []byte) *diffFile {
+	return &diffFile{path: path, hash: plumbing.ComputeHash(plumbing.BlobObject, content)}
+}
+
+func (f *diffFile) Hash() plumbing.Hash     { return f.hash }
+func (f *diffFile) Mode() filemode.FileMode { return filemode.Regular }
+func (f *diffFile) Path() string            { return f.path }
+
+type chunk struct {
+	content string
+	op      fdiff.Operation
+}
+
+func (c *chunk) Content() string       { return c.content }
+func (c *chunk) Type() fdiff.Operation { return c.op }
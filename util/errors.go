@@ -0,0 +1,32 @@
+package util
+
+import "errors"
+
+// RetryableError marks a transient failure, e.g. a network error or a rate
+// limit, where trying the same operation again later may succeed. Errors that
+// are not marked retryable are treated as permanent.
+type RetryableError struct {
+	Err error
+}
+
+func (e *RetryableError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryableError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable marks err as transient. It returns nil if err is nil.
+func Retryable(err error) error {
+	if err == nil || IsRetryable(err) {
+		return err
+	}
+	return &RetryableError{Err: err}
+}
+
+// IsRetryable reports whether any error in err's chain is marked as transient.
+func IsRetryable(err error) bool {
+	var retryable *RetryableError
+	return errors.As(err, &retryable)
+}